@@ -5,8 +5,9 @@ import (
 	"log"
 
 	"github.com/snoofox/snoo/src/cmd"
-	"github.com/snoofox/snoo/src/db"
 	"github.com/snoofox/snoo/src/feed"
+	"github.com/snoofox/snoo/src/paths"
+	"github.com/snoofox/snoo/src/plugin"
 	"github.com/snoofox/snoo/src/providers/hackernews"
 	"github.com/snoofox/snoo/src/providers/lobsters"
 	"github.com/snoofox/snoo/src/providers/reddit"
@@ -14,16 +15,15 @@ import (
 )
 
 func main() {
+	if err := paths.MigrateLegacy(); err != nil {
+		log.Printf("Failed to move ~/.snoo to the XDG directories: %v", err)
+	}
+
 	feed.Register(reddit.New())
 	feed.Register(rss.New())
 	feed.Register(lobsters.New())
 	feed.Register(hackernews.New())
+	feed.RegisterLoader(func() { plugin.RegisterAll(context.Background()) })
 
-	database, err := db.GetDB()
-	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
-	}
-
-	ctx := db.WithDB(context.Background(), database)
-	cmd.Execute(ctx)
+	cmd.Execute(context.Background())
 }
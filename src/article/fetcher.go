@@ -9,8 +9,23 @@ import (
 
 	"github.com/go-shiori/go-readability"
 	"github.com/snoofox/snoo/src/debug"
+	"github.com/snoofox/snoo/src/httpclient"
 )
 
+// defaultTimeout bounds a fetch when no network timeout is configured.
+const defaultTimeout = 15 * time.Second
+
+var timeout = defaultTimeout
+
+// SetTimeout bounds each fetch, retries and reading the page included, at d.
+// Zero keeps the default so the article view never waits indefinitely.
+func SetTimeout(d time.Duration) {
+	if d <= 0 {
+		d = defaultTimeout
+	}
+	timeout = d
+}
+
 func Fetch(ctx context.Context, url string) (string, error) {
 	if url == "" || (!strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://")) {
 		return "", fmt.Errorf("invalid URL")
@@ -22,7 +37,7 @@ func Fetch(ctx context.Context, url string) (string, error) {
 
 	debug.Log("Article: Fetching content from %s", url)
 
-	fetchCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	fetchCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(fetchCtx, "GET", url, nil)
@@ -32,11 +47,7 @@ func Fetch(ctx context.Context, url string) (string, error) {
 	}
 	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; snoo/1.0)")
 
-	client := &http.Client{
-		Timeout: 15 * time.Second,
-	}
-
-	resp, err := client.Do(req)
+	resp, err := httpclient.Default.Do(req)
 	if err != nil {
 		debug.Log("Article: Error fetching URL: %v", err)
 		return "", fmt.Errorf("error fetching URL: %w", err)
@@ -5,37 +5,57 @@ import (
 
 	"github.com/snoofox/snoo/src/db"
 	"github.com/spf13/cobra"
+	"gorm.io/gorm"
 )
 
 var clearCmd = &cobra.Command{
 	Use:   "clear",
 	Short: "Clear cached posts and comments to force fresh fetch",
+	Long: `Clear cached posts, their comments and score history, and reset every
+source's fetch time so the next fetch gets fresh data. Saved posts and posts
+with a note are kept.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		database := db.FromContext(cmd.Context())
 
-		result := database.Unscoped().Delete(&db.Post{}, "1=1")
-		if result.Error != nil {
-			fmt.Printf("Error clearing posts cache: %v\n", result.Error)
-			return
-		}
-		postsDeleted := result.RowsAffected
+		var postsDeleted, commentsDeleted, postsKept int64
+		err := db.Write(database, func(tx *gorm.DB) error {
+			// Saved posts and posts with a note are the reader's, not cache.
+			result := tx.Unscoped().Where("saved_at IS NULL AND note = ''").Delete(&db.Post{})
+			if result.Error != nil {
+				return fmt.Errorf("failed to clear posts cache: %w", result.Error)
+			}
+			postsDeleted = result.RowsAffected
 
-		result = database.Unscoped().Delete(&db.Comment{}, "1=1")
-		if result.Error != nil {
-			fmt.Printf("Error clearing comments cache: %v\n", result.Error)
-			return
-		}
-		commentsDeleted := result.RowsAffected
+			if err := tx.Unscoped().Model(&db.Post{}).Count(&postsKept).Error; err != nil {
+				return fmt.Errorf("failed to count kept posts: %w", err)
+			}
 
-		result = database.Model(&db.Source{}).Where("1=1").Update("last_fetch_at", nil)
-		if result.Error != nil {
-			fmt.Printf("Error resetting source fetch times: %v\n", result.Error)
+			result = tx.Unscoped().Where("post_id NOT IN (SELECT id FROM posts)").Delete(&db.Comment{})
+			if result.Error != nil {
+				return fmt.Errorf("failed to clear comments cache: %w", result.Error)
+			}
+			commentsDeleted = result.RowsAffected
+
+			if err := tx.Where("post_id NOT IN (SELECT id FROM posts)").Delete(&db.PostSnapshot{}).Error; err != nil {
+				return fmt.Errorf("failed to clear score history: %w", err)
+			}
+
+			if err := tx.Model(&db.Source{}).Where("1=1").Update("last_fetch_at", nil).Error; err != nil {
+				return fmt.Errorf("failed to reset source fetch times: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
 			return
 		}
 
 		fmt.Printf("Cache cleared successfully!\n")
 		fmt.Printf("- Deleted %d posts\n", postsDeleted)
 		fmt.Printf("- Deleted %d comments\n", commentsDeleted)
+		if postsKept > 0 {
+			fmt.Printf("- Kept %d saved or annotated posts\n", postsKept)
+		}
 		fmt.Printf("- Reset fetch times for all sources\n")
 		fmt.Printf("\nNext feed fetch will get fresh data.\n")
 	},
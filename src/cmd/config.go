@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/snoofox/snoo/src/article"
+	"github.com/snoofox/snoo/src/config"
+	"github.com/snoofox/snoo/src/debug"
+	"github.com/snoofox/snoo/src/feed"
+	"github.com/snoofox/snoo/src/httpclient"
+	"github.com/spf13/cobra"
+)
+
+// applyConfig pushes the loaded configuration into the packages that use
+// it, then applies command line flags on top.
+func applyConfig(cmd *cobra.Command) {
+	cfg := config.FromContext(cmd.Context())
+
+	if cfg.Theme != "" && !SetTheme(cfg.Theme) {
+		debug.Log("Unknown theme in config: %s", cfg.Theme)
+	}
+
+	httpclient.SetUserAgent(cfg.Network.UserAgent)
+	if err := httpclient.SetProxy(cfg.Network.Proxy); err != nil {
+		debug.Log("Ignoring configured proxy: %v", err)
+	}
+	for provider, value := range cfg.Network.Proxies {
+		if err := httpclient.SetProviderProxy(provider, value); err != nil {
+			debug.Log("Ignoring configured proxy for %s: %v", provider, err)
+		}
+	}
+
+	feed.SetRefreshInterval("", cfg.Refresh.Interval.Duration)
+	for provider, d := range cfg.Refresh.Providers {
+		feed.SetRefreshInterval(provider, d.Duration)
+	}
+	feed.SetRetention(retention(cfg), cfg.Retention.Vacuum.Duration)
+	feed.SetRanking(cfg.Ranking.Gravity, cfg.Ranking.Weights)
+
+	if !cmd.Flag("timeout").Changed {
+		fetchTimeout = cfg.Network.Timeout.Duration
+	}
+	article.SetTimeout(fetchTimeout)
+	if proxyFlag != "" {
+		if err := httpclient.SetProxy(proxyFlag); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show or change settings",
+	Long: `Show or change settings in the config file. Every setting can also be
+overridden with an environment variable named after its key, e.g.
+SNOO_NETWORK_TIMEOUT for network.timeout.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [KEY]",
+	Short: "Print a setting, or all settings",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.FromContext(cmd.Context())
+
+		if len(args) == 1 {
+			value, err := cfg.Get(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Println(value)
+			return
+		}
+
+		for _, key := range config.Keys() {
+			value, _ := cfg.Get(key)
+			fmt.Printf("%-24s %s\n", key, value)
+		}
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set KEY VALUE",
+	Short: "Change a setting in the config file",
+	Example: `  snoo config set theme dracula
+  snoo config set refresh.interval 30m
+  snoo config set refresh.providers.hackernews 10m
+  snoo config set network.proxies.reddit socks5h://127.0.0.1:9050
+  snoo config set keys.down n`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.FromContext(cmd.Context())
+
+		err := cfg.Update(func(c *config.Config) error {
+			return c.Set(args[0], args[1])
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("%s = %s\n", args[0], args[1])
+		if _, ok := os.LookupEnv(config.EnvName(args[0])); ok {
+			fmt.Printf("Note: %s is set and overrides this\n", config.EnvName(args[0]))
+		}
+	},
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the config file in $EDITOR",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.FromContext(cmd.Context())
+		path := cfg.File()
+
+		// Make sure there is a file to edit.
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if err := cfg.Update(func(*config.Config) error { return nil }); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+
+		if err := editFile(cmd.Context(), path); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if _, err := config.Load(cfg.Profile()); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	},
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the config file location",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(config.FromContext(cmd.Context()).File())
+	},
+}
+
+// editFile opens path in $VISUAL or $EDITOR, falling back to vi.
+func editFile(ctx context.Context, path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// The editor may carry arguments, e.g. "code --wait".
+	fields := strings.Fields(editor)
+	c := exec.CommandContext(ctx, fields[0], append(fields[1:], path)...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return c.Run()
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configPathCmd)
+	rootCmd.AddCommand(configCmd)
+}
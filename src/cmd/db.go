@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/snoofox/snoo/src/config"
+	"github.com/snoofox/snoo/src/db"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Inspect and maintain the database",
+}
+
+var dbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show applied and pending schema migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		database := db.FromContext(cmd.Context())
+
+		applied, err := db.Applied(database)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		pending, err := db.Pending(database)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		version := 0
+		if len(applied) > 0 {
+			version = applied[len(applied)-1].Version
+		}
+		fmt.Printf("Schema version: %d\n", version)
+
+		if len(applied) > 0 {
+			fmt.Println("\nApplied:")
+			for _, v := range applied {
+				fmt.Printf("  %3d  %-40s %s\n", v.Version, v.Name, v.AppliedAt.Format("2006-01-02 15:04"))
+			}
+		}
+
+		if len(pending) == 0 {
+			fmt.Println("\nUp to date")
+			return
+		}
+		fmt.Println("\nPending:")
+		for _, m := range pending {
+			fmt.Printf("  %3d  %s\n", m.Version, m.Name)
+		}
+		fmt.Println("\nRun 'snoo db migrate' to apply them.")
+	},
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply pending schema migrations",
+	Long: `Apply pending schema migrations. snoo does this automatically on start;
+this command is for applying them explicitly, e.g. after a failed upgrade.
+The database is backed up next to itself before anything changes.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		database := db.FromContext(cmd.Context())
+
+		applied, backup, err := db.Migrate(database)
+		for _, m := range applied {
+			fmt.Printf("Applied %d: %s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if len(applied) == 0 {
+			fmt.Println("Database is up to date")
+			return
+		}
+		if backup != "" {
+			fmt.Printf("Backup saved to %s\n", backup)
+		}
+	},
+}
+
+var pruneDryRun bool
+
+var dbPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete cached posts the retention settings don't keep",
+	Long: `Delete cached posts the retention settings don't keep: posts no fetch has
+returned for retention.days, posts beyond the newest retention.per_source of
+each source, and posts of sources you unsubscribed from. Saved posts and
+posts with a note are always kept. Score history older than
+retention.snapshot_days is deleted too.
+
+snoo prunes after every refresh; this command is for checking what it would
+delete, or pruning after changing the settings.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.FromContext(cmd.Context())
+		database := db.FromContext(cmd.Context())
+		r := retention(cfg)
+
+		if pruneDryRun {
+			counts, err := db.Prunable(database, r, time.Now())
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+
+			var total int64
+			for _, c := range counts {
+				fmt.Printf("  %6d  %s\n", c.Count, displaySourceName(c.SourceName))
+				total += c.Count
+			}
+			fmt.Printf("Would delete %d posts\n", total)
+			return
+		}
+
+		deleted, err := db.Prune(database, r, time.Now())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("Deleted %d posts\n", deleted)
+
+		vacuumed, err := db.VacuumIfDue(database, cfg.Retention.Vacuum.Duration, time.Now())
+		if err != nil {
+			fmt.Printf("Error vacuuming database: %v\n", err)
+			return
+		}
+		if vacuumed {
+			fmt.Println("Vacuumed database")
+		}
+	},
+}
+
+var dbVacuumCmd = &cobra.Command{
+	Use:   "vacuum",
+	Short: "Compact the database file",
+	Long: `Compact the database file, returning the space of deleted posts to the
+file system. snoo does this every retention.vacuum after pruning.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := db.Vacuum(db.FromContext(cmd.Context())); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Println("Vacuumed database")
+	},
+}
+
+// retention converts the retention settings into a pruning policy.
+func retention(cfg *config.Config) db.Retention {
+	return db.Retention{
+		MaxAge:      time.Duration(cfg.Retention.Days) * 24 * time.Hour,
+		PerSource:   cfg.Retention.PerSource,
+		SnapshotAge: time.Duration(cfg.Retention.SnapshotDays) * 24 * time.Hour,
+	}
+}
+
+// migrateDatabase brings the schema up to date before a command runs,
+// exiting if that fails.
+func migrateDatabase(database *gorm.DB) {
+	applied, backup, err := db.Migrate(database)
+	if err != nil {
+		fmt.Printf("Error: failed to upgrade database: %v\n", err)
+		fmt.Println("Run 'snoo db status' for details.")
+		os.Exit(1)
+	}
+
+	if len(applied) > 0 && backup != "" {
+		fmt.Printf("Upgraded database to schema version %d (backup saved to %s)\n",
+			applied[len(applied)-1].Version, backup)
+	}
+}
+
+func isMigrationCommand(cmd *cobra.Command) bool {
+	return cmd == dbStatusCmd || cmd == dbMigrateCmd
+}
+
+func init() {
+	dbCmd.AddCommand(dbStatusCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbPruneCmd)
+	dbCmd.AddCommand(dbVacuumCmd)
+	dbPruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "show what would be deleted without deleting it")
+	rootCmd.AddCommand(dbCmd)
+}
@@ -0,0 +1,313 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snoofox/snoo/src/config"
+	"github.com/snoofox/snoo/src/db"
+	"github.com/snoofox/snoo/src/feed"
+	"github.com/spf13/cobra"
+)
+
+var (
+	digestSince  string
+	digestFormat string
+	digestTop    int
+	digestOutput string
+	digestMail   bool
+)
+
+// digestFormats are the formats a digest can be written in.
+var digestFormats = []string{"markdown", "html", "text"}
+
+// discussionBases are the sites whose providers give relative permalinks.
+var discussionBases = map[string]string{
+	"reddit":     "https://www.reddit.com",
+	"hackernews": "https://news.ycombinator.com",
+}
+
+type digestSection struct {
+	name  string
+	posts []Post
+}
+
+var digestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Summarize the top recent posts",
+	Long: `Summarize the top posts since a time, one section per group and per
+source outside any group. Posts are ranked as in the hot sort, against the
+others from their source, so small sources aren't buried by large ones.
+
+The digest is printed unless written to a file with -o or mailed with
+--mail, which pipes it to digest.sendmail (default "sendmail -t") addressed
+to digest.to.`,
+	Example: `  snoo digest
+  snoo digest --since 7d --format html -o digest.html
+  snoo config set digest.to me@example.com && snoo digest --mail`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.FromContext(cmd.Context()).Digest
+
+		format := digestFormat
+		if format == "" {
+			format = cfg.Format
+		}
+		if !slices.Contains(digestFormats, format) {
+			fmt.Printf("Error: unknown format %q (%s)\n", format, strings.Join(digestFormats, ", "))
+			return
+		}
+		if digestMail && (cfg.To == "" || strings.TrimSpace(cfg.Sendmail) == "") {
+			fmt.Println("Error: set digest.to, and digest.sendmail if needed, to mail the digest")
+			return
+		}
+
+		top := cfg.Top
+		if cmd.Flag("top").Changed {
+			top = digestTop
+		}
+
+		now := time.Now()
+		since, err := parseTimeFlag(digestSince, now)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		manager := feed.NewManager(db.FromContext(cmd.Context()))
+		manager.SetWindow(since, time.Time{})
+
+		fetchCtx, cancel := fetchContext(cmd.Context())
+		feedPosts, err := manager.FetchAll(fetchCtx)
+		cancel()
+		if err != nil {
+			fmt.Printf("Error fetching feeds: %v\n", err)
+			return
+		}
+
+		sources, err := manager.ListSources()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		sections := digestSections(convertPosts(feedPosts), sources, top)
+		if len(sections) == 0 {
+			fmt.Printf("No posts since %s\n", since.Format("Mon Jan 2 15:04"))
+			return
+		}
+
+		title := "snoo digest for " + now.Format("Monday, 2 January")
+		var buf bytes.Buffer
+		writeDigest(&buf, format, title, since, sections)
+
+		if digestOutput != "" {
+			if err := os.WriteFile(digestOutput, buf.Bytes(), 0644); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Printf("Wrote digest to %s\n", digestOutput)
+		}
+		if digestMail {
+			if err := mailDigest(cmd.Context(), cfg, title, format, buf.Bytes()); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Printf("Mailed digest to %s\n", cfg.To)
+		}
+		if digestOutput == "" && !digestMail {
+			os.Stdout.Write(buf.Bytes())
+		}
+	},
+}
+
+// digestSections gathers posts into a section per group and per source in
+// no group, ordered by name, each keeping its top posts in hot order. A
+// top of zero keeps every post.
+func digestSections(posts []Post, sources []feed.Source, top int) []digestSection {
+	groups := make(map[uint]string, len(sources))
+	for _, s := range sources {
+		groups[s.ID] = s.Group
+	}
+
+	byName := make(map[string]*digestSection)
+	var sections []*digestSection
+	for _, post := range posts {
+		name := groups[post.SourceID]
+		if name == "" {
+			name = displaySourceName(post.SourceName)
+		}
+		section, ok := byName[name]
+		if !ok {
+			section = &digestSection{name: name}
+			byName[name] = section
+			sections = append(sections, section)
+		}
+		section.posts = append(section.posts, post)
+	}
+
+	sort.Slice(sections, func(i, j int) bool {
+		return strings.ToLower(sections[i].name) < strings.ToLower(sections[j].name)
+	})
+
+	result := make([]digestSection, len(sections))
+	for i, section := range sections {
+		sortPosts(section.posts, "hot", func(Post) bool { return false })
+		if top > 0 && len(section.posts) > top {
+			section.posts = section.posts[:top]
+		}
+		result[i] = *section
+	}
+	return result
+}
+
+func writeDigest(w io.Writer, format, title string, since time.Time, sections []digestSection) {
+	subtitle := "Top posts since " + since.Format("Mon Jan 2 15:04")
+
+	switch format {
+	case "markdown":
+		fmt.Fprintf(w, "# %s\n\n%s\n", title, subtitle)
+		for _, section := range sections {
+			fmt.Fprintf(w, "\n## %s\n\n", section.name)
+			for i, post := range section.posts {
+				title := markdownEscaper.Replace(postTitle(post))
+				if link := postLink(post); link != "" {
+					title = fmt.Sprintf("[%s](%s)", title, markdownHref.Replace(link))
+				}
+				fmt.Fprintf(w, "%d. %s\n", i+1, title)
+				fmt.Fprintf(w, "   %s\n", digestDetails(post, markdownEscaper.Replace, func(text, href string) string {
+					return fmt.Sprintf("[%s](%s)", text, markdownHref.Replace(href))
+				}))
+			}
+		}
+
+	case "html":
+		fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head><meta charset=\"utf-8\"><title>%s</title></head>\n<body>\n", html.EscapeString(title))
+		fmt.Fprintf(w, "<h1>%s</h1>\n<p>%s</p>\n", html.EscapeString(title), html.EscapeString(subtitle))
+		for _, section := range sections {
+			fmt.Fprintf(w, "<h2>%s</h2>\n<ol>\n", html.EscapeString(section.name))
+			for _, post := range section.posts {
+				title := html.EscapeString(postTitle(post))
+				if link := postLink(post); link != "" {
+					title = fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(link), title)
+				}
+				fmt.Fprintf(w, "<li>%s", title)
+				fmt.Fprintf(w, "<br><small>%s</small></li>\n", digestDetails(post, html.EscapeString, func(text, href string) string {
+					return fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(href), text)
+				}))
+			}
+			fmt.Fprintln(w, "</ol>")
+		}
+		fmt.Fprintln(w, "</body>\n</html>")
+
+	default:
+		fmt.Fprintf(w, "%s\n%s\n", title, subtitle)
+		for _, section := range sections {
+			fmt.Fprintf(w, "\n%s\n%s\n", section.name, strings.Repeat("=", len([]rune(section.name))))
+			for i, post := range section.posts {
+				fmt.Fprintf(w, "\n%d. %s\n", i+1, postTitle(post))
+				fmt.Fprintf(w, "   %s\n", digestDetails(post, noEscape, func(text, href string) string {
+					return text
+				}))
+				if link := postLink(post); link != "" {
+					fmt.Fprintf(w, "   %s\n", link)
+				}
+				if link := discussionLink(post); link != "" && link != postLink(post) {
+					fmt.Fprintf(w, "   %s\n", link)
+				}
+			}
+		}
+	}
+}
+
+var markdownEscaper = strings.NewReplacer(`<`, `\<`, `[`, `\[`, `]`, `\]`, `*`, `\*`, `_`, `\_`, "`", "\\`")
+
+// markdownHref percent-encodes the characters that would end a markdown
+// link's destination early.
+var markdownHref = strings.NewReplacer(`(`, "%28", `)`, "%29", " ", "%20", "<", "%3C", ">", "%3E")
+
+func noEscape(s string) string {
+	return s
+}
+
+func postTitle(post Post) string {
+	return strings.TrimSpace(html.UnescapeString(post.Title))
+}
+
+// digestDetails describes a post's source, points and comments; link
+// renders the comments' link in the digest's format.
+func digestDetails(post Post, escape func(string) string, link func(text, href string) string) string {
+	parts := []string{escape(displaySourceName(post.SourceName))}
+	if post.Score > 0 {
+		parts = append(parts, fmt.Sprintf("%d points", post.Score))
+	}
+	if post.NumComments > 0 {
+		comments := fmt.Sprintf("%d comments", post.NumComments)
+		if href := discussionLink(post); href != "" {
+			comments = link(comments, href)
+		}
+		parts = append(parts, comments)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// discussionLink returns the address of a post's comments, or "" if it is
+// unknown.
+func discussionLink(post Post) string {
+	switch {
+	case post.CommentsURL != "":
+		return post.CommentsURL
+	case strings.HasPrefix(post.Permalink, "http"):
+		return post.Permalink
+	case post.Permalink != "" && discussionBases[post.SourceType] != "":
+		return discussionBases[post.SourceType] + post.Permalink
+	}
+	return ""
+}
+
+// mailDigest pipes the digest, with mail headers, to the configured
+// sendmail command.
+func mailDigest(ctx context.Context, cfg config.DigestConfig, subject, format string, body []byte) error {
+	contentType := "text/plain"
+	if format == "html" {
+		contentType = "text/html"
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "To: %s\r\n", cfg.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: %s; charset=utf-8\r\n\r\n", contentType)
+	msg.Write(body)
+
+	fields := strings.Fields(cfg.Sendmail)
+	c := exec.CommandContext(ctx, fields[0], fields[1:]...)
+	c.Stdin = &msg
+	out, err := c.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s failed: %w: %s", fields[0], err, msg)
+		}
+		return fmt.Errorf("%s failed: %w", fields[0], err)
+	}
+	return nil
+}
+
+func init() {
+	digestCmd.Flags().StringVar(&digestSince, "since", "24h", "summarize posts created since this time or span ago, e.g. 24h, 7d or 2024-05-01")
+	digestCmd.Flags().StringVar(&digestFormat, "format", "", "markdown, html or text (default digest.format)")
+	digestCmd.Flags().IntVar(&digestTop, "top", 0, "posts per section, 0 for all (default digest.top)")
+	digestCmd.Flags().StringVarP(&digestOutput, "output", "o", "", "write the digest to this file")
+	digestCmd.Flags().BoolVar(&digestMail, "mail", false, "mail the digest with digest.sendmail to digest.to")
+	rootCmd.AddCommand(digestCmd)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/snoofox/snoo/src/db"
+	"github.com/snoofox/snoo/src/media"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var downloadOutput string
+
+var downloadCmd = &cobra.Command{
+	Use:   "download [POST]",
+	Short: "Download a post's media enclosure (podcast episode, etc.)",
+	Long: `Download the media attached to a post. POST is the ID shown by
+'snoo download' without arguments, or the post's GUID.
+
+Interrupted downloads resume where they stopped when run again.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		database := db.FromContext(cmd.Context())
+
+		if len(args) == 0 {
+			listEnclosures(database)
+			return
+		}
+
+		post, err := findPost(database, args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if post.EnclosureURL == "" {
+			fmt.Println("Error: post has no media attached")
+			return
+		}
+
+		dest := downloadOutput
+		if dest == "" {
+			dest = media.FileName(post.EnclosureURL)
+		} else if info, err := os.Stat(dest); err == nil && info.IsDir() {
+			dest = filepath.Join(dest, media.FileName(post.EnclosureURL))
+		}
+
+		fmt.Printf("Downloading %s\n", post.Title)
+		err = media.Download(cmd.Context(), post.EnclosureURL, dest, func(written, total int64) {
+			if total > 0 {
+				fmt.Printf("\r  %s / %s (%d%%)", formatBytes(written), formatBytes(total), written*100/total)
+			} else {
+				fmt.Printf("\r  %s", formatBytes(written))
+			}
+		})
+		fmt.Println()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			fmt.Println("Run the command again to resume.")
+			return
+		}
+
+		fmt.Printf("Saved to %s\n", dest)
+	},
+}
+
+// findPost looks a post up by its database ID or external ID.
+func findPost(database *gorm.DB, ref string) (*db.Post, error) {
+	var post db.Post
+	if id, err := strconv.ParseUint(ref, 10, 32); err == nil {
+		if err := database.First(&post, id).Error; err == nil {
+			return &post, nil
+		}
+	}
+
+	if err := database.Where("external_id = ?", ref).Order("created_utc DESC").First(&post).Error; err != nil {
+		return nil, fmt.Errorf("post not found: %s", ref)
+	}
+	return &post, nil
+}
+
+func listEnclosures(database *gorm.DB) {
+	var posts []db.Post
+	database.Where("enclosure_url <> ''").Order("created_utc DESC").Limit(20).Find(&posts)
+
+	if len(posts) == 0 {
+		fmt.Println("No posts with media found")
+		return
+	}
+
+	fmt.Println("Recent posts with media:")
+	fmt.Println()
+	for _, p := range posts {
+		fmt.Printf("%d. %s\n", p.ID, truncate(p.Title, 80))
+		fmt.Printf("   %s", p.SourceName)
+		if p.EnclosureLength > 0 {
+			fmt.Printf(" • %s", formatBytes(p.EnclosureLength))
+		}
+		fmt.Println()
+	}
+	fmt.Println("\nUsage: snoo download <id>")
+}
+
+func init() {
+	downloadCmd.Flags().StringVarP(&downloadOutput, "output", "o", "", "file or directory to save to")
+	rootCmd.AddCommand(downloadCmd)
+}
@@ -3,16 +3,20 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/snoofox/snoo/src/article"
+	"github.com/snoofox/snoo/src/config"
 	"github.com/snoofox/snoo/src/db"
 	"github.com/snoofox/snoo/src/debug"
 	"github.com/snoofox/snoo/src/feed"
+	"github.com/snoofox/snoo/src/media"
 	"github.com/spf13/cobra"
 )
 
@@ -38,6 +42,10 @@ type articleLoadedMsg struct {
 	err     error
 }
 
+type playerExitedMsg struct {
+	err error
+}
+
 type postKey struct {
 	typ string
 	id  string
@@ -49,6 +57,8 @@ type sortOption struct {
 }
 
 var sortOptions = []sortOption{
+	{name: "Hot (balanced across sources)", key: "hot"},
+	{name: "Trending", key: "trending"},
 	{name: "Most Upvotes", key: "upvotes_desc"},
 	{name: "Least Upvotes", key: "upvotes_asc"},
 	{name: "Newest First", key: "newest"},
@@ -77,13 +87,16 @@ type model struct {
 	filtering          bool
 	sorting            bool
 	commentSorting     bool
+	choosingView       bool
 	filterCursor       int
 	sortCursor         int
 	commentSortCursor  int
+	viewCursor         int
 	selected           int
 	width              int
 	height             int
 	comments           []Comment
+	history            []feed.Snapshot
 	loadingComments    bool
 	loadingArticle     bool
 	ctx                context.Context
@@ -91,11 +104,20 @@ type model struct {
 	ready              bool
 	sources            []string
 	sourceEnabled      map[string]bool
+	tags               []string
+	tagSelected        map[string]bool
+	sourceInfo         map[uint]feed.Source
+	maxPerSource       int
+	maxAge             time.Duration // posts older than this are hidden, 0 for none
+	groups             []string      // one tab each, after the All tab
+	tab                int           // 0 for All, else 1 + index into groups
+	view               string        // the saved view shown, "" for none
 	currentSort        string
 	currentCommentSort string
 	originalContent    string
 	articleContent     string
 	showingArticle     bool
+	keys               keyMap
 }
 
 func (m model) Init() tea.Cmd {
@@ -139,9 +161,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.viewport.SetContent(m.renderPostContent())
 		m.viewport.GotoTop()
 
+	case playerExitedMsg:
+		if msg.err != nil {
+			debug.Log("Media player exited with error: %v", msg.err)
+		}
+
 	case tea.KeyMsg:
+		key := m.keys.translate(msg.String())
 		if m.commentSorting {
-			switch msg.String() {
+			switch key {
 			case "q", "esc", "backspace":
 				m.commentSorting = false
 				m.commentSortCursor = 0
@@ -164,8 +192,33 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.savePreferences()
 				return m, nil
 			}
+		} else if m.choosingView {
+			names := viewNames(config.FromContext(m.ctx).Views)
+			switch key {
+			case "q", "esc", "backspace":
+				m.choosingView = false
+				m.viewCursor = 0
+				return m, nil
+			case "up", "k":
+				if m.viewCursor > 0 {
+					m.viewCursor--
+				}
+			case "down", "j":
+				if m.viewCursor < len(names) {
+					m.viewCursor++
+				}
+			case "enter", " ":
+				name := ""
+				if m.viewCursor > 0 {
+					name = names[m.viewCursor-1]
+				}
+				m.selectView(name)
+				m.choosingView = false
+				m.viewCursor = 0
+				return m, nil
+			}
 		} else if m.sorting {
-			switch msg.String() {
+			switch key {
 			case "q", "esc", "backspace":
 				m.sorting = false
 				m.sortCursor = 0
@@ -180,14 +233,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			case "enter", " ":
 				m.currentSort = sortOptions[m.sortCursor].key
-				m.applySorting()
+				m.applyFilters()
 				m.sorting = false
 				m.sortCursor = 0
-				m.savePreferences()
+				// A view's sort lasts only while it is shown.
+				if m.view == "" {
+					m.savePreferences()
+				}
 				return m, nil
 			}
 		} else if m.filtering {
-			switch msg.String() {
+			switch key {
 			case "q", "esc", "backspace":
 				m.filtering = false
 				m.filterCursor = 0
@@ -197,12 +253,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.filterCursor--
 				}
 			case "down", "j":
-				if m.filterCursor < len(m.sources)-1 {
+				if m.filterCursor < len(m.sources)+len(m.tags)-1 {
 					m.filterCursor++
 				}
 			case "enter", " ":
-				source := m.sources[m.filterCursor]
-				m.sourceEnabled[source] = !m.sourceEnabled[source]
+				if m.filterCursor < len(m.sources) {
+					source := m.sources[m.filterCursor]
+					m.sourceEnabled[source] = !m.sourceEnabled[source]
+				} else {
+					tag := m.tags[m.filterCursor-len(m.sources)]
+					m.tagSelected[tag] = !m.tagSelected[tag]
+				}
 				m.applyFilters()
 				m.savePreferences()
 				return m, nil
@@ -210,6 +271,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				for _, source := range m.sources {
 					m.sourceEnabled[source] = true
 				}
+				for _, tag := range m.tags {
+					m.tagSelected[tag] = false
+				}
 				m.applyFilters()
 				m.savePreferences()
 				return m, nil
@@ -222,10 +286,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 		} else if m.viewing {
-			switch msg.String() {
+			switch key {
 			case "q", "esc", "backspace":
 				m.viewing = false
 				m.comments = nil
+				m.history = nil
 				m.loadingComments = false
 				m.loadingArticle = false
 				m.originalContent = ""
@@ -257,6 +322,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				}
 				return m, nil
+			case "p":
+				post := m.posts[m.selected]
+				if post.EnclosureURL != "" {
+					return m, playMediaCmd(post.EnclosureURL)
+				}
+				return m, nil
+			case "b":
+				m.toggleSaved(m.selected)
+				return m, nil
 			case "g":
 				m.viewport.GotoTop()
 				return m, nil
@@ -271,17 +345,43 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, cmd
 			}
 		} else {
-			switch msg.String() {
+			switch key {
 			case "q", "ctrl+c":
 				return m, tea.Quit
 			case "f":
-				m.filtering = true
-				m.filterCursor = 0
+				// A view's own conditions replace the filters.
+				if m.view == "" {
+					m.filtering = true
+					m.filterCursor = 0
+				}
+				return m, nil
+			case "v":
+				m.choosingView = true
+				m.viewCursor = 0
+				return m, nil
+			case "w":
+				m.cycleWindow()
 				return m, nil
 			case "s":
 				m.sorting = true
 				m.sortCursor = 0
 				return m, nil
+			case "b":
+				if len(m.posts) > 0 {
+					m.toggleSaved(m.cursor)
+				}
+				return m, nil
+			case "tab":
+				m.switchTab((m.tab + 1) % (len(m.groups) + 1))
+				return m, nil
+			case "shift+tab":
+				m.switchTab((m.tab + len(m.groups)) % (len(m.groups) + 1))
+				return m, nil
+			case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+				if tab := int(key[0] - '1'); tab <= len(m.groups) {
+					m.switchTab(tab)
+				}
+				return m, nil
 			case "up", "k":
 				if m.cursor > 0 {
 					m.cursor--
@@ -298,10 +398,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.originalContent = ""
 				m.articleContent = ""
 				m.showingArticle = false
+
+				post := m.posts[m.selected]
+				m.history = m.loadHistory(post)
 				m.viewport.SetContent(m.renderPostContent())
 				m.viewport.GotoTop()
 
-				post := m.posts[m.selected]
 				if !post.IsRead {
 					m.posts[m.selected].IsRead = true
 					go m.markPostAsRead(post)
@@ -320,14 +422,137 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// toggleSaved saves or unsaves the post at index i of the list, which
+// keeps it from being pruned.
+func (m *model) toggleSaved(i int) {
+	post := m.posts[i]
+	saved := !post.IsSaved
+
+	m.posts[i].IsSaved = saved
+	for j := range m.allPosts {
+		if m.allPosts[j].SourceType == post.SourceType && m.allPosts[j].ID == post.ID {
+			m.allPosts[j].IsSaved = saved
+		}
+	}
+
+	manager := feed.NewManager(db.FromContext(m.ctx))
+	go func() {
+		if err := manager.SetSaved(m.ctx, post.SourceType, post.ID, saved); err != nil {
+			debug.Log("Failed to save post: %v", err)
+		}
+	}()
+}
+
+// group returns the group of the current tab, or "" on the All tab.
+func (m *model) group() string {
+	if m.tab == 0 {
+		return ""
+	}
+	return m.groups[m.tab-1]
+}
+
+func (m *model) inTab(post Post) bool {
+	return m.tab == 0 || m.sourceInfo[post.SourceID].Group == m.group()
+}
+
+// switchTab shows the posts of another tab with that tab's preferences.
+func (m *model) switchTab(tab int) {
+	if tab == m.tab {
+		return
+	}
+	m.tab = tab
+
+	srcSet := make(map[string]bool)
+	for _, post := range m.allPosts {
+		if m.inTab(post) {
+			srcSet[post.SourceName] = true
+		}
+	}
+	m.sources = make([]string, 0, len(srcSet))
+	for src := range srcSet {
+		m.sources = append(m.sources, src)
+	}
+	sort.Strings(m.sources)
+
+	m.currentSort, m.currentCommentSort, m.sourceEnabled = loadPreferences(m.ctx, m.group(), m.sources)
+	m.tagSelected = loadTagPreferences(m.ctx, m.group(), m.tags)
+	if view, ok := m.savedView(); ok && view.Sort != "" {
+		m.currentSort = view.Sort
+	}
+	m.cursor = 0
+	m.applyFilters()
+}
+
+// ageWindows are the age windows the window key cycles through; zero
+// shows every post.
+var ageWindows = []time.Duration{24 * time.Hour, 7 * 24 * time.Hour, 0}
+
+// cycleWindow widens the age window to the next of ageWindows, or narrows
+// it back to the first once every post is shown, and saves it.
+func (m *model) cycleWindow() {
+	next := ageWindows[0]
+	if m.maxAge > 0 {
+		for _, w := range ageWindows {
+			if w == 0 || w > m.maxAge {
+				next = w
+				break
+			}
+		}
+	}
+	m.maxAge = next
+	m.applyFilters()
+
+	if err := db.SetSetting(db.FromContext(m.ctx), feedStateKey("", "max_age"), next.String()); err != nil {
+		debug.Log("Failed to save age window: %v", err)
+	}
+}
+
+// savedView returns the saved view shown, if any.
+func (m *model) savedView() (config.View, bool) {
+	if m.view == "" {
+		return config.View{}, false
+	}
+	view, ok := config.FromContext(m.ctx).Views[m.view]
+	return view, ok
+}
+
+// selectView shows the posts of a saved view in its sort, or the filtered
+// feed again if name is empty.
+func (m *model) selectView(name string) {
+	m.view = name
+	if view, ok := m.savedView(); ok && view.Sort != "" {
+		m.currentSort = view.Sort
+	} else {
+		m.currentSort, _, _ = loadPreferences(m.ctx, m.group(), m.sources)
+	}
+	m.cursor = 0
+	m.applyFilters()
+}
+
 func (m *model) applyFilters() {
-	m.posts = m.posts[:0]
+	view, inView := m.savedView()
+	now := time.Now()
+
+	m.posts = make([]Post, 0, len(m.allPosts))
 	for i := range m.allPosts {
-		if m.sourceEnabled[m.allPosts[i].SourceName] {
-			m.posts = append(m.posts, m.allPosts[i])
+		post := m.allPosts[i]
+		if !m.inTab(post) {
+			continue
 		}
+		// A view has its own conditions, age included, in place of the
+		// feed's filters and window.
+		if inView {
+			if !matchesView(view, post, now) {
+				continue
+			}
+		} else if !m.sourceEnabled[post.SourceName] || !m.matchesTags(post) ||
+			m.maxAge > 0 && postAge(post, now) > m.maxAge {
+			continue
+		}
+		m.posts = append(m.posts, post)
 	}
 	m.applySorting()
+	m.capPerSource()
 	if m.cursor >= len(m.posts) {
 		m.cursor = len(m.posts) - 1
 		if m.cursor < 0 {
@@ -336,41 +561,118 @@ func (m *model) applyFilters() {
 	}
 }
 
+// capPerSource drops the posts of each source beyond its cap, keeping the
+// first ones in the current order.
+func (m *model) capPerSource() {
+	shown := make(map[uint]int)
+	kept := m.posts[:0]
+	for _, post := range m.posts {
+		limit := m.maxPerSource
+		if src, ok := m.sourceInfo[post.SourceID]; ok && src.MaxPosts > 0 {
+			limit = src.MaxPosts
+		}
+		if limit > 0 && shown[post.SourceID] >= limit {
+			continue
+		}
+		shown[post.SourceID]++
+		kept = append(kept, post)
+	}
+	m.posts = kept
+}
+
+// matchesTags reports whether post carries one of the selected tags. With no
+// tags selected every post matches.
+func (m *model) matchesTags(post Post) bool {
+	selected := false
+	for _, tag := range m.tags {
+		if m.tagSelected[tag] {
+			selected = true
+			break
+		}
+	}
+	if !selected {
+		return true
+	}
+
+	for _, tag := range post.Tags {
+		if m.tagSelected[tag] {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *model) applySorting() {
-	switch m.currentSort {
+	sortPosts(m.posts, m.currentSort, m.pinned)
+}
+
+// sortPosts orders posts by sortKey, one of the keys of sortOptions, with
+// those pinned first.
+func sortPosts(posts []Post, sortKey string, pinned func(Post) bool) {
+	switch sortKey {
+	case "hot":
+		sort.Slice(posts, func(i, j int) bool {
+			return posts[i].Hot > posts[j].Hot
+		})
+	case "trending":
+		sort.Slice(posts, func(i, j int) bool {
+			return posts[i].Velocity > posts[j].Velocity
+		})
 	case "upvotes_desc":
-		sort.Slice(m.posts, func(i, j int) bool {
-			return m.posts[i].Score > m.posts[j].Score
+		sort.Slice(posts, func(i, j int) bool {
+			return posts[i].Score > posts[j].Score
 		})
 	case "upvotes_asc":
-		sort.Slice(m.posts, func(i, j int) bool {
-			return m.posts[i].Score < m.posts[j].Score
+		sort.Slice(posts, func(i, j int) bool {
+			return posts[i].Score < posts[j].Score
 		})
 	case "newest":
-		sort.Slice(m.posts, func(i, j int) bool {
-			return m.posts[i].CreatedUTC > m.posts[j].CreatedUTC
+		sort.Slice(posts, func(i, j int) bool {
+			return posts[i].CreatedUTC > posts[j].CreatedUTC
 		})
 	case "oldest":
-		sort.Slice(m.posts, func(i, j int) bool {
-			return m.posts[i].CreatedUTC < m.posts[j].CreatedUTC
+		sort.Slice(posts, func(i, j int) bool {
+			return posts[i].CreatedUTC < posts[j].CreatedUTC
 		})
 	case "comments_desc":
-		sort.Slice(m.posts, func(i, j int) bool {
-			return m.posts[i].NumComments > m.posts[j].NumComments
+		sort.Slice(posts, func(i, j int) bool {
+			return posts[i].NumComments > posts[j].NumComments
 		})
 	case "comments_asc":
-		sort.Slice(m.posts, func(i, j int) bool {
-			return m.posts[i].NumComments < m.posts[j].NumComments
+		sort.Slice(posts, func(i, j int) bool {
+			return posts[i].NumComments < posts[j].NumComments
 		})
 	default:
 		// Default: smart sort (upvotes if available, otherwise newest)
-		sort.Slice(m.posts, func(i, j int) bool {
-			if m.posts[i].Score > 0 && m.posts[j].Score > 0 {
-				return m.posts[i].Score > m.posts[j].Score
+		sort.Slice(posts, func(i, j int) bool {
+			if posts[i].Score > 0 && posts[j].Score > 0 {
+				return posts[i].Score > posts[j].Score
 			}
-			return m.posts[i].CreatedUTC > m.posts[j].CreatedUTC
+			return posts[i].CreatedUTC > posts[j].CreatedUTC
 		})
 	}
+
+	// Posts from pinned sources come first, in the chosen order.
+	sort.SliceStable(posts, func(i, j int) bool {
+		return pinned(posts[i]) && !pinned(posts[j])
+	})
+}
+
+// validSort reports whether key is one of the keys of sortOptions.
+func validSort(key string) bool {
+	return slices.Contains(sortKeys(), key)
+}
+
+func sortKeys() []string {
+	keys := make([]string, len(sortOptions))
+	for i, opt := range sortOptions {
+		keys[i] = opt.key
+	}
+	return keys
+}
+
+func (m *model) pinned(post Post) bool {
+	return m.sourceInfo[post.SourceID].Pinned
 }
 
 func (m *model) applyCommentSorting() {
@@ -413,19 +715,45 @@ func sortComments(comments []Comment, sortKey string) []Comment {
 	return sorted
 }
 
-func (m *model) savePreferences() {
-	database := db.FromContext(m.ctx)
-	if database == nil {
-		return
+// The feed remembers the sorts, filters and age window last picked in each
+// tab in the settings table, keeping config.toml for what the user writes.
+// The [feed] settings are the defaults until something is picked, and win
+// over what was picked once the config file or environment changes them.
+
+// feedStateKey names the setting holding one of the feed's remembered
+// choices for a group's tab, or for the All tab if group is empty.
+func feedStateKey(group, name string) string {
+	if group == "" {
+		return "feed_state." + name
 	}
+	return "feed_state.group." + group + "." + name
+}
 
-	if m.currentSort != "" {
-		db.SetSetting(database, "feed_sort", m.currentSort)
+// loadFeedState returns the remembered choice stored under key, if any.
+func loadFeedState(ctx context.Context, key string) (string, bool) {
+	value, err := db.GetSetting(db.FromContext(ctx), key)
+	return value, err == nil
+}
+
+// remembered returns a tab's remembered choice for name, unless configKey
+// is changed from its default, which then applies instead.
+func remembered(ctx context.Context, group, name, configKey string) (string, bool) {
+	if configKey != "" && !config.FromContext(ctx).IsDefault(configKey) {
+		return "", false
 	}
+	return loadFeedState(ctx, feedStateKey(group, name))
+}
 
-	if m.currentCommentSort != "" {
-		db.SetSetting(database, "comment_sort", m.currentCommentSort)
+// splitFeedState splits a remembered list, stored one item per line.
+func splitFeedState(value string) []string {
+	if value == "" {
+		return nil
 	}
+	return strings.Split(value, "\n")
+}
+
+func (m *model) savePreferences() {
+	database := db.FromContext(m.ctx)
 
 	var enabledSources []string
 	for _, src := range m.sources {
@@ -433,47 +761,111 @@ func (m *model) savePreferences() {
 			enabledSources = append(enabledSources, src)
 		}
 	}
+
+	var selectedTags []string
+	for _, tag := range m.tags {
+		if m.tagSelected[tag] {
+			selectedTags = append(selectedTags, tag)
+		}
+	}
+
+	group := m.group()
+	state := map[string]string{
+		feedStateKey(group, "tags"): strings.Join(selectedTags, "\n"),
+	}
+	if m.currentCommentSort != "" {
+		state[feedStateKey("", "comment_sort")] = m.currentCommentSort
+	}
+	if m.currentSort != "" {
+		state[feedStateKey(group, "sort")] = m.currentSort
+	}
 	if len(enabledSources) > 0 {
-		db.SetSetting(database, "feed_sources", strings.Join(enabledSources, ","))
+		state[feedStateKey(group, "sources")] = strings.Join(enabledSources, "\n")
+	}
+
+	for key, value := range state {
+		if err := db.SetSetting(database, key, value); err != nil {
+			debug.Log("Failed to save preferences: %v", err)
+			return
+		}
 	}
 }
 
-func loadPreferences(ctx context.Context, sources []string) (string, string, map[string]bool) {
-	database := db.FromContext(ctx)
-	sourceEnabled := make(map[string]bool, len(sources))
+// loadPreferences returns the sort, comment sort and enabled sources of a
+// group's tab, or of the All tab if group is empty. feed.sources and
+// feed.tags only apply to the All tab.
+func loadPreferences(ctx context.Context, group string, sources []string) (string, string, map[string]bool) {
+	cfg := config.FromContext(ctx)
 
-	for _, src := range sources {
-		sourceEnabled[src] = true
+	sortPref := cfg.Feed.Sort
+	if value, ok := remembered(ctx, group, "sort", "feed.sort"); ok && value != "" {
+		sortPref = value
 	}
 
-	if database == nil {
-		return "upvotes_desc", "best", sourceEnabled
+	var enabledSources []string
+	sourcesKey := ""
+	if group == "" {
+		enabledSources, sourcesKey = cfg.Feed.Sources, "feed.sources"
+	}
+	if value, ok := remembered(ctx, group, "sources", sourcesKey); ok {
+		enabledSources = splitFeedState(value)
 	}
 
-	sortPref, err := db.GetSetting(database, "feed_sort")
-	if err != nil || sortPref == "" {
+	sourceEnabled := make(map[string]bool, len(sources))
+	for _, src := range sources {
+		sourceEnabled[src] = len(enabledSources) == 0
+	}
+	for _, enabled := range enabledSources {
+		if _, exists := sourceEnabled[enabled]; exists {
+			sourceEnabled[enabled] = true
+		}
+	}
+
+	if sortPref == "" {
 		sortPref = "upvotes_desc"
 	}
 
-	commentSortPref, err := db.GetSetting(database, "comment_sort")
-	if err != nil || commentSortPref == "" {
+	commentSortPref := cfg.Feed.CommentSort
+	if value, ok := remembered(ctx, "", "comment_sort", "feed.comment_sort"); ok && value != "" {
+		commentSortPref = value
+	}
+	if commentSortPref == "" {
 		commentSortPref = "best"
 	}
 
-	sourcesStr, err := db.GetSetting(database, "feed_sources")
-	if err == nil && sourcesStr != "" {
-		enabledList := strings.Split(sourcesStr, ",")
-		for _, src := range sources {
-			sourceEnabled[src] = false
-		}
-		for _, enabled := range enabledList {
-			if _, exists := sourceEnabled[enabled]; exists {
-				sourceEnabled[enabled] = true
-			}
+	return sortPref, commentSortPref, sourceEnabled
+}
+
+func loadTagPreferences(ctx context.Context, group string, tags []string) map[string]bool {
+	var selected []string
+	tagsKey := ""
+	if group == "" {
+		selected, tagsKey = config.FromContext(ctx).Feed.Tags, "feed.tags"
+	}
+	if value, ok := remembered(ctx, group, "tags", tagsKey); ok {
+		selected = splitFeedState(value)
+	}
+
+	tagSelected := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tagSelected[tag] = false
+	}
+	for _, tag := range selected {
+		if _, exists := tagSelected[tag]; exists {
+			tagSelected[tag] = true
 		}
 	}
+	return tagSelected
+}
 
-	return sortPref, commentSortPref, sourceEnabled
+// loadMaxAge returns the age window the feed opens with.
+func loadMaxAge(ctx context.Context) time.Duration {
+	if value, ok := remembered(ctx, "", "max_age", "feed.max_age"); ok {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return config.FromContext(ctx).Feed.MaxAge.Duration
 }
 
 func (m model) View() string {
@@ -483,6 +875,9 @@ func (m model) View() string {
 	if m.sorting {
 		return m.viewSort()
 	}
+	if m.choosingView {
+		return m.viewViews()
+	}
 	if m.filtering {
 		return m.viewFilter()
 	}
@@ -568,6 +963,50 @@ func (m model) viewSort() string {
 	return b.String()
 }
 
+func (m model) viewViews() string {
+	var b strings.Builder
+	b.WriteString("\n")
+	b.WriteString(titleStyle.Render("  Saved Views"))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("  Add views with 'snoo view add'"))
+	b.WriteString("\n\n")
+
+	views := config.FromContext(m.ctx).Views
+	for i, name := range append([]string{""}, viewNames(views)...) {
+		indicator := " "
+		if name == m.view {
+			indicator = "●"
+		}
+
+		label := "Filtered feed"
+		if name != "" {
+			label = name + dimStyle.Render("  "+describeView(views[name]))
+		}
+
+		line := fmt.Sprintf("  %s %s", indicator, label)
+		if i == m.viewCursor {
+			b.WriteString(cursorStyle.Render("● "))
+			b.WriteString(selectedStyle.Render(line))
+		} else {
+			b.WriteString("  ")
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+
+	t := GetCurrentTheme()
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("  "))
+	b.WriteString(lipgloss.NewStyle().Foreground(t.HelpNav).Render("j/k"))
+	b.WriteString(dimStyle.Render(" navigate  "))
+	b.WriteString(lipgloss.NewStyle().Foreground(t.HelpAction).Render("enter"))
+	b.WriteString(dimStyle.Render(" select  "))
+	b.WriteString(lipgloss.NewStyle().Foreground(t.HelpQuit).Render("esc"))
+	b.WriteString(dimStyle.Render(" back"))
+
+	return b.String()
+}
+
 func (m model) viewFilter() string {
 	var b strings.Builder
 	b.WriteString("\n")
@@ -593,6 +1032,29 @@ func (m model) viewFilter() string {
 		b.WriteString("\n")
 	}
 
+	if len(m.tags) > 0 {
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render("  Only show posts tagged"))
+		b.WriteString("\n\n")
+	}
+
+	for i, tag := range m.tags {
+		box := "○"
+		if m.tagSelected[tag] {
+			box = "●"
+		}
+
+		line := fmt.Sprintf("  %s #%s", box, tag)
+		if len(m.sources)+i == m.filterCursor {
+			b.WriteString(cursorStyle.Render("● "))
+			b.WriteString(selectedStyle.Render(line))
+		} else {
+			b.WriteString("  ")
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+
 	t := GetCurrentTheme()
 	b.WriteString("\n")
 	b.WriteString(dimStyle.Render("  "))
@@ -616,6 +1078,9 @@ func (m model) viewList() string {
 	}
 
 	headerLines := 4
+	if len(m.groups) > 0 {
+		headerLines++
+	}
 	linesPerPost := 3
 	availableLines := m.height - 2
 
@@ -635,8 +1100,19 @@ func (m model) viewList() string {
 	}
 
 	s := "\n"
-	s += titleStyle.Render("  󰑍  Your Feed") + "\n"
-	s += dimStyle.Render(fmt.Sprintf("  %d posts", len(m.posts))) + "\n\n"
+	s += titleStyle.Render("  󰑍  Your Feed")
+	if m.view != "" {
+		s += dimStyle.Render("  " + m.view)
+	}
+	s += "\n"
+	if len(m.groups) > 0 {
+		s += m.renderTabs() + "\n"
+	}
+	count := fmt.Sprintf("  %d posts", len(m.posts))
+	if m.maxAge > 0 {
+		count += " from the last " + formatAge(m.maxAge)
+	}
+	s += dimStyle.Render(count) + "\n\n"
 
 	for i := firstVisiblePost; i < lastVisiblePost; i++ {
 		post := m.posts[i]
@@ -664,6 +1140,20 @@ func (m model) viewList() string {
 				metadata += commentsStyle.Render(fmt.Sprintf("󰆉 %d", post.NumComments))
 			}
 
+			if len(post.Tags) > 0 {
+				if metadata != "" {
+					metadata += " " + sep + " "
+				}
+				metadata += dimStyle.Render("#" + strings.Join(post.Tags, " #"))
+			}
+
+			if post.IsSaved {
+				if metadata != "" {
+					metadata += " " + sep + " "
+				}
+				metadata += scoreStyle.Render("󰃀 saved")
+			}
+
 			s += " " + cursor + selectedStyle.Render(titleText) + "\n"
 			if metadata != "" {
 				s += "   " + nsfw + sub + " " + sep + " " + metadata + "\n\n"
@@ -691,6 +1181,20 @@ func (m model) viewList() string {
 				metadata += commentsStyle.Render(fmt.Sprintf("󰆉 %d", post.NumComments))
 			}
 
+			if len(post.Tags) > 0 {
+				if metadata != "" {
+					metadata += " " + sep + " "
+				}
+				metadata += dimStyle.Render("#" + strings.Join(post.Tags, " #"))
+			}
+
+			if post.IsSaved {
+				if metadata != "" {
+					metadata += " " + sep + " "
+				}
+				metadata += scoreStyle.Render("󰃀 saved")
+			}
+
 			titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#E5E7EB"))
 			if post.IsRead {
 				titleStyle = dimStyle
@@ -708,27 +1212,59 @@ func (m model) viewList() string {
 	theme := GetCurrentTheme()
 	helpText := dimStyle.Render("  ") +
 		lipgloss.NewStyle().Foreground(theme.HelpNav).Render("j/k") +
-		dimStyle.Render(" navigate  ") +
-		lipgloss.NewStyle().Foreground(theme.HelpAction).Render("s") +
-		dimStyle.Render(" sort  ") +
-		lipgloss.NewStyle().Foreground(theme.HelpAction).Render("f") +
-		dimStyle.Render(" filter  ") +
+		dimStyle.Render(" navigate  ")
+	if len(m.groups) > 0 {
+		helpText += lipgloss.NewStyle().Foreground(theme.HelpNav).Render("tab") +
+			dimStyle.Render(" group  ")
+	}
+	helpText += lipgloss.NewStyle().Foreground(theme.HelpAction).Render("s") +
+		dimStyle.Render(" sort  ")
+	if m.view == "" {
+		helpText += lipgloss.NewStyle().Foreground(theme.HelpAction).Render("f") +
+			dimStyle.Render(" filter  ")
+	}
+	helpText += lipgloss.NewStyle().Foreground(theme.HelpAction).Render("v") +
+		dimStyle.Render(" view  ") +
+		lipgloss.NewStyle().Foreground(theme.HelpAction).Render("w") +
+		dimStyle.Render(" age  ") +
+		lipgloss.NewStyle().Foreground(theme.HelpAction).Render("b") +
+		dimStyle.Render(" save  ") +
 		lipgloss.NewStyle().Foreground(theme.HelpQuit).Render("q") +
 		dimStyle.Render(" quit")
 	return s + helpText
 }
 
+// renderTabs draws the All tab and one per group, numbered for their keys.
+func (m model) renderTabs() string {
+	names := append([]string{feed.AllGroup}, m.groups...)
+
+	tabs := make([]string, len(names))
+	for i, name := range names {
+		label := fmt.Sprintf("%d %s", i+1, name)
+		if i > 8 {
+			label = name
+		}
+		if i == m.tab {
+			tabs[i] = selectedStyle.Render(label)
+		} else {
+			tabs[i] = dimStyle.Render(label)
+		}
+	}
+	return "  " + strings.Join(tabs, "   ")
+}
+
 func (m model) loadCommentsCmd() tea.Cmd {
 	return func() tea.Msg {
 		post := m.posts[m.selected]
-		if post.SourceType == "reddit" || post.SourceType == "lobsters" || post.SourceType == "hackernews" {
+		if hasComments(post) {
 			database := db.FromContext(m.ctx)
 			manager := feed.NewManager(database)
 
 			feedPost := feed.Post{
-				ID:         post.ID,
-				SourceType: post.SourceType,
-				Permalink:  post.Permalink,
+				ID:          post.ID,
+				SourceType:  post.SourceType,
+				Permalink:   post.Permalink,
+				CommentsURL: post.CommentsURL,
 			}
 
 			feedComments, err := manager.FetchComments(m.ctx, feedPost)
@@ -746,6 +1282,22 @@ func (m model) loadCommentsCmd() tea.Cmd {
 	}
 }
 
+// hasComments reports whether comments can be fetched for post. RSS posts
+// only have comments when the feed links a per-post comment feed; plugins
+// say whether they serve comments.
+func hasComments(post Post) bool {
+	switch post.SourceType {
+	case "reddit", "lobsters", "hackernews":
+		return true
+	}
+	if provider, err := feed.Get(post.SourceType); err == nil {
+		if r, ok := provider.(feed.CommentsReporter); ok && r.HasComments() {
+			return true
+		}
+	}
+	return post.CommentsURL != ""
+}
+
 func (m model) loadArticleCmd() tea.Cmd {
 	return func() tea.Msg {
 		post := m.posts[m.selected]
@@ -757,6 +1309,22 @@ func (m model) loadArticleCmd() tea.Cmd {
 	}
 }
 
+func playMediaCmd(mediaURL string) tea.Cmd {
+	debug.Log("Playing %s", mediaURL)
+	return tea.ExecProcess(media.PlayerCommand(mediaURL), func(err error) tea.Msg {
+		return playerExitedMsg{err: err}
+	})
+}
+
+func (m *model) loadHistory(post Post) []feed.Snapshot {
+	manager := feed.NewManager(db.FromContext(m.ctx))
+	history, err := manager.History(post.SourceType, post.ID)
+	if err != nil {
+		debug.Log("Failed to load post history: %v", err)
+	}
+	return history
+}
+
 func (m *model) markPostAsRead(post Post) {
 	database := db.FromContext(m.ctx)
 	if database == nil {
@@ -769,6 +1337,56 @@ func (m *model) markPostAsRead(post Post) {
 	}
 }
 
+// convertPosts converts fetched posts for display, dropping any fetched
+// twice.
+func convertPosts(feedPosts []feed.Post) []Post {
+	seen := make(map[postKey]bool, len(feedPosts))
+	posts := make([]Post, 0, len(feedPosts))
+	for _, p := range feedPosts {
+		k := postKey{p.SourceType, p.ID}
+		if !seen[k] {
+			seen[k] = true
+			posts = append(posts, convertPost(p))
+		}
+	}
+	return posts
+}
+
+func convertPost(p feed.Post) Post {
+	post := Post{
+		ID:          p.ID,
+		Title:       p.Title,
+		Author:      p.Author,
+		SourceName:  p.SourceName,
+		SourceType:  p.SourceType,
+		Permalink:   p.Permalink,
+		URL:         p.URL,
+		Score:       p.Score,
+		NumComments: p.NumComments,
+		Velocity:    p.Velocity,
+		Hot:         p.Hot,
+		SourceID:    p.SourceID,
+		CreatedUTC:  float64(p.CreatedAt.Unix()),
+		Content:     p.Content,
+		Thumbnail:   p.Thumbnail,
+		NSFW:        p.NSFW,
+		IsRead:      p.ReadAt != nil,
+		IsSaved:     p.SavedAt != nil,
+		Note:        p.Note,
+		CommentsURL: p.CommentsURL,
+		Tags:        p.Tags,
+	}
+
+	if p.Enclosure != nil {
+		post.EnclosureURL = p.Enclosure.URL
+		post.EnclosureType = p.Enclosure.Type
+		post.EnclosureLength = p.Enclosure.Length
+		post.EnclosureDuration = p.Enclosure.Duration
+	}
+
+	return post
+}
+
 func convertComment(c feed.Comment) Comment {
 	replies := make([]Comment, len(c.Replies))
 	for i, r := range c.Replies {
@@ -794,6 +1412,18 @@ func (m model) renderPostContent() string {
 
 	s := "\n" + titleStyle.Render(wrapText(post.Title, maxWidth)) + "\n\n"
 
+	if len(m.history) > 1 {
+		s += renderHistory(m.history) + "\n\n"
+	}
+
+	if post.Note != "" {
+		s += commentsStyle.Render("󰏫 "+wrapText(post.Note, maxWidth-2)) + "\n\n"
+	}
+
+	if post.EnclosureURL != "" {
+		s += renderEnclosure(post) + "\n\n"
+	}
+
 	if m.loadingArticle {
 		s += dimStyle.Render("Loading article...") + "\n"
 	} else if m.showingArticle && m.articleContent != "" {
@@ -813,7 +1443,7 @@ func (m model) renderPostContent() string {
 		}
 	}
 
-	if post.SourceType == "reddit" || post.SourceType == "lobsters" || post.SourceType == "hackernews" {
+	if hasComments(post) {
 		s += "\n" + dimStyle.Render(fmt.Sprintf("─── %d comments ───", post.NumComments)) + "\n\n"
 
 		if m.loadingComments {
@@ -830,6 +1460,42 @@ func (m model) renderPostContent() string {
 	return s
 }
 
+// renderHistory draws how a post's score and comment count changed over
+// the fetches that saw it.
+func renderHistory(history []feed.Snapshot) string {
+	scores := make([]int, len(history))
+	comments := make([]int, len(history))
+	for i, s := range history {
+		scores[i] = s.Score
+		comments[i] = s.NumComments
+	}
+
+	first, last := history[0], history[len(history)-1]
+	span := formatAge(last.TakenAt.Sub(first.TakenAt))
+	sep := separatorStyle.Render(" • ")
+
+	return scoreStyle.Render(fmt.Sprintf(" %s %d", sparkline(scores), last.Score)) +
+		dimStyle.Render(fmt.Sprintf(" (%+d in %s)", last.Score-first.Score, span)) + sep +
+		commentsStyle.Render(fmt.Sprintf("󰆉 %s %d", sparkline(comments), last.NumComments)) +
+		dimStyle.Render(fmt.Sprintf(" (%+d)", last.NumComments-first.NumComments))
+}
+
+func renderEnclosure(post Post) string {
+	sep := separatorStyle.Render(" • ")
+	parts := []string{commentsStyle.Render("󰎆 " + post.EnclosureType)}
+	if post.EnclosureType == "" {
+		parts[0] = commentsStyle.Render("󰎆 media")
+	}
+	if post.EnclosureDuration > 0 {
+		parts = append(parts, scoreStyle.Render(formatDuration(post.EnclosureDuration)))
+	}
+	if post.EnclosureLength > 0 {
+		parts = append(parts, dimStyle.Render(formatBytes(post.EnclosureLength)))
+	}
+
+	return strings.Join(parts, sep) + "\n" + urlStyle.Render(post.EnclosureURL)
+}
+
 func (m model) viewPost() string {
 	post := m.posts[m.selected]
 	theme := GetCurrentTheme()
@@ -857,6 +1523,12 @@ func (m model) viewPost() string {
 		}
 	}
 
+	if post.EnclosureURL != "" {
+		helpParts = append(helpParts,
+			lipgloss.NewStyle().Foreground(theme.HelpAction).Render("p")+
+				dimStyle.Render(" play"))
+	}
+
 	if len(m.comments) > 0 {
 		helpParts = append(helpParts,
 			lipgloss.NewStyle().Foreground(theme.HelpAction).Render("s")+
@@ -907,16 +1579,86 @@ func renderComment(c Comment, w int) string {
 	return b.String()
 }
 
+// newModel sets up the feed viewer over posts with the saved preferences
+// of the All tab.
+func newModel(ctx context.Context, manager *feed.Manager, posts []Post) model {
+	srcSet := make(map[string]bool, 10)
+	for i := range posts {
+		srcSet[posts[i].SourceName] = true
+	}
+	srcs := make([]string, 0, len(srcSet))
+	for src := range srcSet {
+		srcs = append(srcs, src)
+	}
+	sort.Strings(srcs)
+
+	tagSet := make(map[string]bool)
+	for i := range posts {
+		for _, tag := range posts[i].Tags {
+			tagSet[tag] = true
+		}
+	}
+	tags := make([]string, 0, len(tagSet))
+	for tag := range tagSet {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	sortPref, commentSortPref, srcEnabled := loadPreferences(ctx, "", srcs)
+
+	sourceInfo := make(map[uint]feed.Source)
+	if sources, err := manager.ListSources(); err == nil {
+		for _, s := range sources {
+			sourceInfo[s.ID] = s
+		}
+	}
+
+	groups, err := manager.Groups()
+	if err != nil {
+		debug.Log("Failed to load groups: %v", err)
+	}
+
+	return model{
+		posts:              posts,
+		allPosts:           posts,
+		ctx:                ctx,
+		sources:            srcs,
+		sourceEnabled:      srcEnabled,
+		tags:               tags,
+		tagSelected:        loadTagPreferences(ctx, "", tags),
+		currentSort:        sortPref,
+		currentCommentSort: commentSortPref,
+		keys:               newKeyMap(config.FromContext(ctx).Keys),
+		sourceInfo:         sourceInfo,
+		maxPerSource:       config.FromContext(ctx).Feed.MaxPerSource,
+		maxAge:             loadMaxAge(ctx),
+		groups:             groups,
+	}
+}
+
+// feedView opens the feed in a saved view.
+var feedView string
+
 var feedCmd = &cobra.Command{
 	Use:   "feed",
 	Short: "List hot posts from all sources",
 	Run: func(cmd *cobra.Command, args []string) {
-		loadSavedTheme(cmd.Context())
+		if _, ok := config.FromContext(cmd.Context()).Views[feedView]; feedView != "" && !ok {
+			fmt.Printf("Error: view %s does not exist (see 'snoo view list')\n", feedView)
+			return
+		}
 
 		database := db.FromContext(cmd.Context())
 		manager := feed.NewManager(database)
 
-		feedPosts, err := manager.FetchAll(cmd.Context())
+		// Ctrl-C or --timeout during the initial load stops fetching; the
+		// feed then opens with whatever is already cached.
+		fetchCtx, cancel := fetchContext(cmd.Context())
+		feedPosts, err := manager.FetchAll(fetchCtx)
+		if fetchCtx.Err() != nil {
+			fmt.Println("Fetch interrupted, showing cached posts")
+		}
+		cancel()
 		if err != nil {
 			fmt.Printf("Error fetching feeds: %v\n", err)
 			return
@@ -929,60 +1671,8 @@ var feedCmd = &cobra.Command{
 			return
 		}
 
-		posts := make([]Post, len(feedPosts))
-		for i, p := range feedPosts {
-			posts[i] = Post{
-				ID:          p.ID,
-				Title:       p.Title,
-				Author:      p.Author,
-				SourceName:  p.SourceName,
-				SourceType:  p.SourceType,
-				Permalink:   p.Permalink,
-				URL:         p.URL,
-				Score:       p.Score,
-				NumComments: p.NumComments,
-				CreatedUTC:  float64(p.CreatedAt.Unix()),
-				Content:     p.Content,
-				Thumbnail:   p.Thumbnail,
-				NSFW:        p.NSFW,
-				IsRead:      p.ReadAt != nil,
-			}
-		}
-
-		seen := make(map[postKey]bool, len(posts))
-		deduped := make([]Post, 0, len(posts))
-		for i := range posts {
-			k := postKey{posts[i].SourceType, posts[i].ID}
-			if !seen[k] {
-				seen[k] = true
-				deduped = append(deduped, posts[i])
-			}
-		}
-		posts = deduped
-
-		srcSet := make(map[string]bool, 10)
-		for i := range posts {
-			srcSet[posts[i].SourceName] = true
-		}
-		srcs := make([]string, 0, len(srcSet))
-		for src := range srcSet {
-			srcs = append(srcs, src)
-		}
-		sort.Strings(srcs)
-
-		sortPref, commentSortPref, srcEnabled := loadPreferences(cmd.Context(), srcs)
-
-		m := model{
-			posts:              posts,
-			allPosts:           posts,
-			ctx:                cmd.Context(),
-			sources:            srcs,
-			sourceEnabled:      srcEnabled,
-			currentSort:        sortPref,
-			currentCommentSort: commentSortPref,
-		}
-
-		m.applyFilters()
+		m := newModel(cmd.Context(), manager, convertPosts(feedPosts))
+		m.selectView(feedView)
 
 		p := tea.NewProgram(m, tea.WithAltScreen())
 		if _, err := p.Run(); err != nil {
@@ -991,15 +1681,8 @@ var feedCmd = &cobra.Command{
 	},
 }
 
-func loadSavedTheme(ctx context.Context) {
-	if gormDB := db.FromContext(ctx); gormDB != nil {
-		if themeName, err := db.GetSetting(gormDB, "theme"); err == nil && themeName != "" {
-			SetTheme(themeName)
-		}
-	}
-}
-
 func init() {
 	applyTheme()
+	feedCmd.Flags().StringVar(&feedView, "view", "", "open the feed in a saved view")
 	rootCmd.AddCommand(feedCmd)
 }
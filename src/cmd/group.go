@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/snoofox/snoo/src/db"
+	"github.com/snoofox/snoo/src/feed"
+	"github.com/spf13/cobra"
+)
+
+var groupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "Manage source groups",
+	Long: `Manage source groups. Each group gets its own tab in the feed, with its
+own sort and filters. Put a source in a group with
+'snoo sub set <id> group <name>'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		groupListCmd.Run(cmd, args)
+	},
+}
+
+var groupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List groups and their sources",
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := feed.NewManager(db.FromContext(cmd.Context()))
+
+		groups, err := manager.Groups()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if len(groups) == 0 {
+			fmt.Println("No groups")
+			fmt.Println("Create one with: snoo group add <name>")
+			return
+		}
+
+		sources, err := manager.ListSources()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		for _, group := range groups {
+			fmt.Println(group)
+			for _, src := range sources {
+				if src.Group == group {
+					fmt.Printf("  %d. [%s] %s\n", src.ID, src.Type, src.DisplayName)
+				}
+			}
+		}
+	},
+}
+
+var groupAddCmd = &cobra.Command{
+	Use:   "add NAME",
+	Short: "Create a group",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := feed.NewManager(db.FromContext(cmd.Context()))
+		if err := manager.AddGroup(args[0]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("Created group %s\n", args[0])
+	},
+}
+
+var groupRmCmd = &cobra.Command{
+	Use:   "rm NAME",
+	Short: "Delete a group, keeping its sources",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		manager := feed.NewManager(db.FromContext(cmd.Context()))
+		if err := manager.RemoveGroup(name); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		err := db.DeleteSettings(db.FromContext(cmd.Context()),
+			feedStateKey(name, "sort"), feedStateKey(name, "sources"), feedStateKey(name, "tags"))
+		if err != nil {
+			fmt.Printf("Warning: failed to remove the group's preferences: %v\n", err)
+		}
+
+		fmt.Printf("Removed group %s\n", name)
+	},
+}
+
+func init() {
+	groupCmd.AddCommand(groupListCmd)
+	groupCmd.AddCommand(groupAddCmd)
+	groupCmd.AddCommand(groupRmCmd)
+	rootCmd.AddCommand(groupCmd)
+}
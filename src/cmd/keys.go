@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"github.com/snoofox/snoo/src/debug"
+)
+
+// keyActions are the rebindable feed viewer actions and their default keys.
+var keyActions = map[string]string{
+	"up":          "k",
+	"down":        "j",
+	"top":         "g",
+	"bottom":      "G",
+	"open":        "enter",
+	"back":        "esc",
+	"quit":        "q",
+	"sort":        "s",
+	"filter":      "f",
+	"views":       "v",
+	"window":      "w",
+	"article":     "r",
+	"play":        "p",
+	"save":        "b",
+	"next_tab":    "tab",
+	"prev_tab":    "shift+tab",
+	"enable_all":  "a",
+	"disable_all": "d",
+}
+
+// keyMap translates pressed keys into the default key of the action they
+// are bound to, so the viewer only has to handle the defaults.
+type keyMap map[string]string
+
+// newKeyMap builds a keyMap from configured bindings of action to key. A
+// rebound action no longer answers to its default key; the arrow keys and
+// other alternates keep working.
+func newKeyMap(bindings map[string]string) keyMap {
+	km := make(keyMap)
+
+	for action, key := range bindings {
+		def, ok := keyActions[action]
+		if !ok {
+			debug.Log("Unknown key binding action: %s", action)
+			continue
+		}
+		if key != def {
+			km[def] = ""
+		}
+	}
+	for action, key := range bindings {
+		if def, ok := keyActions[action]; ok && key != "" {
+			km[key] = def
+		}
+	}
+
+	return km
+}
+
+func (km keyMap) translate(key string) string {
+	if def, ok := km[key]; ok {
+		return def
+	}
+	return key
+}
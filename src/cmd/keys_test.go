@@ -0,0 +1,31 @@
+package cmd
+
+import "testing"
+
+func TestKeyMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		bindings map[string]string
+		pressed  string
+		want     string
+	}{
+		{"default", nil, "j", "j"},
+		{"unbound key", nil, "x", "x"},
+		{"rebound", map[string]string{"down": "n"}, "n", "j"},
+		{"old key released", map[string]string{"down": "n"}, "j", ""},
+		{"alternate kept", map[string]string{"down": "n"}, "down", "down"},
+		{"same as default", map[string]string{"down": "j"}, "j", "j"},
+		{"swapped", map[string]string{"down": "k", "up": "j"}, "j", "k"},
+		{"swapped back", map[string]string{"down": "k", "up": "j"}, "k", "j"},
+		{"taken from another action", map[string]string{"quit": "s"}, "s", "q"},
+		{"empty releases", map[string]string{"save": ""}, "b", ""},
+		{"unknown action", map[string]string{"jump": "x"}, "x", "x"},
+		{"named key", map[string]string{"next_tab": "ctrl+n"}, "ctrl+n", "tab"},
+	}
+
+	for _, tt := range tests {
+		if got := newKeyMap(tt.bindings).translate(tt.pressed); got != tt.want {
+			t.Errorf("%s: %v translates %q to %q, want %q", tt.name, tt.bindings, tt.pressed, got, tt.want)
+		}
+	}
+}
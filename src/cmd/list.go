@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/snoofox/snoo/src/config"
+	"github.com/snoofox/snoo/src/db"
+	"github.com/snoofox/snoo/src/feed"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listView  string
+	listLimit int
+	listSince string
+	listUntil string
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print the posts the feed shows",
+	Long: `Print the posts the feed would show, with its filters and sort, or those
+of a saved view, without opening the viewer.`,
+	Example: `  snoo list
+  snoo list --view rust-high -n 10
+  snoo list --since 48h
+  snoo list --since 2024-05-01 --until 2024-05-08`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if _, ok := config.FromContext(cmd.Context()).Views[listView]; listView != "" && !ok {
+			fmt.Printf("Error: view %s does not exist (see 'snoo view list')\n", listView)
+			return
+		}
+
+		manager := feed.NewManager(db.FromContext(cmd.Context()))
+
+		now := time.Now()
+		var since, until time.Time
+		var err error
+		if listSince != "" {
+			if since, err = parseTimeFlag(listSince, now); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+		if listUntil != "" {
+			if until, err = parseTimeFlag(listUntil, now); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+		manager.SetWindow(since, until)
+
+		fetchCtx, cancel := fetchContext(cmd.Context())
+		feedPosts, err := manager.FetchAll(fetchCtx)
+		cancel()
+		if err != nil {
+			fmt.Printf("Error fetching feeds: %v\n", err)
+			return
+		}
+
+		m := newModel(cmd.Context(), manager, convertPosts(feedPosts))
+		if listSince != "" || listUntil != "" {
+			// The flags replace the feed's age window.
+			m.maxAge = 0
+		}
+		m.selectView(listView)
+
+		posts := m.posts
+		if listLimit > 0 && len(posts) > listLimit {
+			posts = posts[:listLimit]
+		}
+		for _, post := range posts {
+			fmt.Println(formatListPost(post))
+		}
+	},
+}
+
+// formatListPost renders a post as two lines: its source, title and counts,
+// then its link.
+func formatListPost(post Post) string {
+	var counts []string
+	if post.Score > 0 {
+		counts = append(counts, fmt.Sprintf("%d points", post.Score))
+	}
+	if post.NumComments > 0 {
+		counts = append(counts, fmt.Sprintf("%d comments", post.NumComments))
+	}
+
+	s := fmt.Sprintf("[%s] %s", displaySourceName(post.SourceName), truncate(post.Title, 100))
+	if len(counts) > 0 {
+		s += " (" + strings.Join(counts, ", ") + ")"
+	}
+	if link := postLink(post); link != "" {
+		s += "\n  " + link
+	}
+	return s
+}
+
+// postLink returns the address a post links to, or its page on its source
+// if it links nowhere else.
+func postLink(post Post) string {
+	if post.URL != "" {
+		return post.URL
+	}
+	if strings.HasPrefix(post.Permalink, "http") {
+		return post.Permalink
+	}
+	return post.CommentsURL
+}
+
+func init() {
+	listCmd.Flags().StringVar(&listView, "view", "", "print the posts of a saved view")
+	listCmd.Flags().IntVarP(&listLimit, "limit", "n", 0, "print at most this many posts")
+	listCmd.Flags().StringVar(&listSince, "since", "", "only print posts created since this time or span ago, e.g. 48h, 7d or 2024-05-01")
+	listCmd.Flags().StringVar(&listUntil, "until", "", "only print posts created before this time or span ago")
+	rootCmd.AddCommand(listCmd)
+}
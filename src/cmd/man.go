@@ -15,13 +15,18 @@ snoo - A terminal feed reader that doesn't suck (yet).
 
 COMMANDS:
   snoo                       Open feed (default)
-  snoo sub add <name>        Subscribe to a subreddit
+  snoo sub <url>             Subscribe to any supported URL or shorthand
+  snoo sub add <name>        Subscribe to a subreddit (name[:sort], a+b, u/<user>,
+                             <user>/m/<multi>, search:<query>[@<sub>]; top/week etc.)
   snoo sub rss <url>         Subscribe to an RSS feed
-  snoo sub lobsters <cat>    Subscribe to Lobsters (active or recent)
-  snoo sub hn <cat>          Subscribe to HackerNews (top, new, best, ask, show, job)
+  snoo sub lobsters <feed>   Subscribe to Lobsters (active, recent, newest, t/<tag>, domain/<domain>, ~<user>)
+  snoo sub hn <feed>         Subscribe to HackerNews (top, new, best, ask, show, job,
+                             search:<query>, user:<name>, front:<date>; --min-points N)
+  snoo sub source <p> <id>   Subscribe using any provider, including plugins
   snoo sub list              List all subscriptions
   snoo sub rm <id>           Remove a subscription
   snoo theme <name>          Change theme (default, catppuccin, dracula, github, peppermint)
+  snoo download [id]         Download a post's media (lists recent media without id)
   snoo clear                 Clear all data
   snoo man                   Show manual with navigation keys
 
@@ -41,6 +46,7 @@ Post View:
   g             Go to top
   G             Go to bottom
   r             Read full article (toggle between original and article)
+  p             Play attached media ($SNOO_PLAYER, defaults to mpv)
   s             Sort comments (by score, date)
   Esc/Backspace/q Back to feed list
   q             Back to feed list
@@ -54,7 +60,7 @@ Sort Menu:
 Filter Menu:
   j / ↓         Move down
   k / ↑         Move up
-  Space         Toggle source on/off
+  Space         Toggle source on/off, or a tag (only show posts with selected tags)
   a             Enable all sources
   d             Disable all sources
   Esc/Backspace Back to feed list
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/snoofox/snoo/src/db"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var noteCmd = &cobra.Command{
+	Use:   "note POST [TEXT]",
+	Short: "Show or set a note on a post",
+	Long: `Show or set a note on a post. POST is the post's ID or GUID, as for
+'snoo download'. Posts with a note are never pruned; set an empty note to
+remove it.`,
+	Example: `  snoo note 1234 "read this before the meeting"
+  snoo note 1234 ""`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		database := db.FromContext(cmd.Context())
+
+		post, err := findPost(database, args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if len(args) == 1 {
+			if post.Note == "" {
+				fmt.Println("No note")
+				return
+			}
+			fmt.Println(post.Note)
+			return
+		}
+
+		err = db.Write(database, func(tx *gorm.DB) error {
+			return tx.Model(post).Update("note", args[1]).Error
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if args[1] == "" {
+			fmt.Printf("Removed note from %s\n", truncate(post.Title, 60))
+		} else {
+			fmt.Printf("Saved note on %s\n", truncate(post.Title, 60))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(noteCmd)
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/snoofox/snoo/src/config"
+	"github.com/snoofox/snoo/src/db"
+	"github.com/snoofox/snoo/src/paths"
+	"github.com/spf13/cobra"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage profiles",
+	Long: `Manage profiles. Each profile has its own subscriptions, cached posts and
+settings. Select one with --profile <name> or SNOO_PROFILE.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		profileListCmd.Run(cmd, args)
+	},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	Run: func(cmd *cobra.Command, args []string) {
+		profiles, err := paths.Profiles()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		current := config.FromContext(cmd.Context()).Profile()
+		for _, name := range profiles {
+			marker := "  "
+			if name == current {
+				marker = "* "
+			}
+			fmt.Printf("%s%s\n", marker, name)
+		}
+	},
+}
+
+var profileCreateCmd = &cobra.Command{
+	Use:   "create NAME",
+	Short: "Create an empty profile",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		if err := checkNewProfile(name); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if err := createProfile(name); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("Created profile %s\n", name)
+		fmt.Printf("Use it with: snoo --profile %s\n", name)
+	},
+}
+
+var profileCopyCmd = &cobra.Command{
+	Use:   "copy FROM TO",
+	Short: "Copy a profile's subscriptions, posts and settings to a new profile",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		from, to := args[0], args[1]
+		if !profileExists(from) {
+			fmt.Printf("Error: profile %s does not exist\n", from)
+			return
+		}
+		if err := checkNewProfile(to); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if err := createProfile(to); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fromDB, err := config.CopyProfile(from, to)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if fromDB == "" {
+			if fromDB, err = profileDatabase(from); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+		toDB, err := profileDatabase(to)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if _, err := os.Stat(fromDB); err == nil {
+			if err := db.CopyTo(fromDB, toDB); err != nil {
+				fmt.Printf("Error copying database: %v\n", err)
+				return
+			}
+		}
+
+		fmt.Printf("Copied profile %s to %s\n", from, to)
+	},
+}
+
+var profileRmCmd = &cobra.Command{
+	Use:   "rm NAME",
+	Short: "Delete a profile with its database and settings",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		if name == paths.DefaultProfile {
+			fmt.Println("Error: the default profile can't be removed")
+			return
+		}
+		if name == config.FromContext(cmd.Context()).Profile() {
+			fmt.Println("Error: can't remove the profile in use")
+			return
+		}
+		if !profileExists(name) {
+			fmt.Printf("Error: profile %s does not exist\n", name)
+			return
+		}
+
+		for _, dir := range []func(string) (string, error){paths.ProfileConfigDir, paths.ProfileDataDir} {
+			path, err := dir(name)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			if err := os.RemoveAll(path); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+
+		fmt.Printf("Removed profile %s\n", name)
+	},
+}
+
+func profileExists(name string) bool {
+	profiles, err := paths.Profiles()
+	if err != nil {
+		return false
+	}
+	for _, p := range profiles {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+func checkNewProfile(name string) error {
+	if err := paths.CheckProfile(name); err != nil {
+		return err
+	}
+	if profileExists(name) {
+		return fmt.Errorf("profile %s already exists", name)
+	}
+	return nil
+}
+
+// createProfile makes the profile's directories and writes its config file
+// with default settings.
+func createProfile(name string) error {
+	dataDir, err := paths.ProfileDataDir(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(name)
+	if err != nil {
+		return err
+	}
+	return cfg.Update(func(*config.Config) error { return nil })
+}
+
+func init() {
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileCreateCmd)
+	profileCmd.AddCommand(profileCopyCmd)
+	profileCmd.AddCommand(profileRmCmd)
+	rootCmd.AddCommand(profileCmd)
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/snoofox/snoo/src/config"
+	"github.com/snoofox/snoo/src/feed"
+	"github.com/snoofox/snoo/src/httpclient"
+	"github.com/spf13/cobra"
+)
+
+// proxyFlag overrides the configured global proxy for one run.
+var proxyFlag string
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Show or change the network proxy",
+	Long: `Show or change the proxy snoo connects through. Proxies can be http://,
+https://, socks5:// or socks5h:// URLs, or "direct" to bypass any proxy from
+the environment. Each provider can override the global proxy.
+
+Onion addresses are only fetched through a SOCKS5 proxy such as Tor.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.FromContext(cmd.Context())
+
+		show := func(label, value string) {
+			if value == "" {
+				value = "(not set)"
+			}
+			fmt.Printf("  %-12s %s\n", label, value)
+		}
+
+		fmt.Println("Proxies:")
+		show("global", cfg.Network.Proxy)
+		for _, provider := range feed.List() {
+			show(provider, cfg.Network.Proxies[provider])
+		}
+	},
+}
+
+var proxySetCmd = &cobra.Command{
+	Use:   "set [PROVIDER] URL",
+	Short: "Set the global proxy or a provider's proxy",
+	Example: `  snoo proxy set http://proxy.example.com:3128
+  snoo proxy set socks5h://127.0.0.1:9050    # Tor
+  snoo proxy set reddit socks5h://127.0.0.1:9050
+  snoo proxy set hackernews direct`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		provider, value := "", args[0]
+		if len(args) == 2 {
+			provider, value = args[0], args[1]
+			if _, err := feed.Get(provider); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+
+		if _, err := httpclient.ParseProxy(value); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		cfg := config.FromContext(cmd.Context())
+		if err := cfg.Update(func(c *config.Config) error { return setProxy(c, provider, value) }); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if provider == "" {
+			fmt.Printf("Proxy set to %s\n", value)
+		} else {
+			fmt.Printf("Proxy for %s set to %s\n", provider, value)
+		}
+	},
+}
+
+var proxyRmCmd = &cobra.Command{
+	Use:   "rm [PROVIDER]",
+	Short: "Remove the global proxy or a provider's override",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		provider := ""
+		if len(args) == 1 {
+			provider = args[0]
+		}
+
+		cfg := config.FromContext(cmd.Context())
+		if err := cfg.Update(func(c *config.Config) error { return setProxy(c, provider, "") }); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		if provider == "" {
+			fmt.Println("Global proxy removed")
+		} else {
+			fmt.Printf("Proxy override for %s removed\n", provider)
+		}
+	},
+}
+
+// setProxy sets the global proxy, or a provider's override when provider
+// is given. An empty value removes it.
+func setProxy(c *config.Config, provider, value string) error {
+	if provider == "" {
+		c.Network.Proxy = value
+		return nil
+	}
+	return c.Set("network.proxies."+provider, value)
+}
+
+func init() {
+	proxyCmd.AddCommand(proxySetCmd)
+	proxyCmd.AddCommand(proxyRmCmd)
+	rootCmd.AddCommand(proxyCmd)
+}
@@ -2,15 +2,36 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
 
+	"github.com/snoofox/snoo/src/config"
+	"github.com/snoofox/snoo/src/db"
+	"github.com/snoofox/snoo/src/paths"
 	"github.com/spf13/cobra"
 )
 
+// profileFlag selects the profile; dbFlag overrides its database path.
+var (
+	profileFlag string
+	dbFlag      string
+)
+
+// fetchTimeout bounds how long commands wait on the network. Zero means no
+// deadline.
+var fetchTimeout time.Duration
+
 var rootCmd = &cobra.Command{
 	Use:   "snoo",
 	Short: "A terminal feed reader that doesn't suck (yet)",
 	Long:  "snoo - A terminal feed reader that doesn't suck (yet).\n\nA fast, keyboard-driven feed reader for Reddit, RSS, Lobsters, and Hacker News.",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		loadProfile(cmd)
+		applyConfig(cmd)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		feedCmd.Run(cmd, args)
 	},
@@ -21,3 +42,87 @@ func Execute(ctx context.Context) {
 		os.Exit(1)
 	}
 }
+
+// loadProfile loads the selected profile's config and opens its database,
+// adding both to the command's context. --db, or a db_path setting,
+// replaces the profile's database.
+func loadProfile(cmd *cobra.Command) {
+	profile := profileFlag
+	if profile == "" {
+		profile = os.Getenv("SNOO_PROFILE")
+	}
+	if profile == "" {
+		profile = paths.DefaultProfile
+	}
+	if err := paths.CheckProfile(profile); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load(profile)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	path := cfg.DBPath
+	if dbFlag != "" {
+		path = dbFlag
+	}
+	if path == "" {
+		path, err = profileDatabase(profile)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	database, err := db.Open(path)
+	if err != nil {
+		fmt.Printf("Error: failed to open database: %v\n", err)
+		os.Exit(1)
+	}
+
+	// snoo db status and migrate inspect and run migrations themselves.
+	if !isMigrationCommand(cmd) {
+		migrateDatabase(database)
+
+		if err := config.MigrateSettings(cfg, database); err != nil {
+			fmt.Printf("Warning: failed to move settings to %s: %v\n", cfg.File(), err)
+		}
+	}
+
+	ctx := config.WithConfig(cmd.Context(), cfg)
+	cmd.SetContext(db.WithDB(ctx, database))
+}
+
+// profileDatabase returns the default database file of a profile.
+func profileDatabase(profile string) (string, error) {
+	dir, err := paths.ProfileDataDir(profile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "data.sqlite3"), nil
+}
+
+// fetchContext derives a context for network work from parent that is
+// cancelled on Ctrl-C or when --timeout expires.
+func fetchContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt)
+	if fetchTimeout <= 0 {
+		return ctx, stop
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Profile to use (also SNOO_PROFILE)")
+	rootCmd.PersistentFlags().StringVar(&dbFlag, "db", "", "Database file to use instead of the configured one (also SNOO_DB)")
+	rootCmd.PersistentFlags().DurationVar(&fetchTimeout, "timeout", 0, "Give up on fetches after this long, e.g. 30s (0 waits indefinitely)")
+	rootCmd.PersistentFlags().StringVar(&proxyFlag, "proxy", "", "Proxy for this run: http(s)://, socks5(h):// or direct")
+}
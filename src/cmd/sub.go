@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/snoofox/snoo/src/db"
 	"github.com/snoofox/snoo/src/feed"
@@ -10,8 +12,54 @@ import (
 )
 
 var subCmd = &cobra.Command{
-	Use:   "sub",
-	Short: "Manage subscriptions",
+	Use:   "sub [URL]",
+	Short: "Subscribe to a URL or manage subscriptions",
+	Long: `Subscribe to any supported URL or shorthand, or manage subscriptions.
+
+The provider is picked from the URL:
+  snoo sub https://www.reddit.com/r/golang/top
+  snoo sub r/golang
+  snoo sub https://news.ycombinator.com/newest
+  snoo sub hn/best
+  snoo sub lobste.rs
+  snoo sub https://github.com/charmbracelet/bubbletea
+  snoo sub https://lwn.net/headlines/rss`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			cmd.Help()
+			return
+		}
+
+		// A bare word is more likely a mistyped subcommand than a source.
+		if !strings.ContainsAny(args[0], "/.:") {
+			fmt.Printf("Error: unknown command %q for \"snoo sub\"\n", args[0])
+			if suggestions := cmd.SuggestionsFor(args[0]); len(suggestions) > 0 {
+				fmt.Printf("\nDid you mean this?\n\t%s\n", strings.Join(suggestions, "\n\t"))
+			}
+			fmt.Println("\nTo subscribe, give a URL or a shorthand such as r/golang or hn/best.")
+			return
+		}
+
+		ctx, cancel := fetchContext(cmd.Context())
+		defer cancel()
+		database := db.FromContext(ctx)
+		manager := feed.NewManager(database)
+
+		providerType, identifier, err := feed.Resolve(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("Subscribing to %s (%s)...\n", identifier, providerType)
+		if err := manager.Subscribe(ctx, providerType, identifier); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("Successfully subscribed to %s\n", identifier)
+	},
 }
 
 var subListCmd = &cobra.Command{
@@ -30,10 +78,13 @@ var subListCmd = &cobra.Command{
 		if len(sources) == 0 {
 			fmt.Println("No subscribed sources")
 			fmt.Println("\nAvailable commands:")
-			fmt.Println("  snoo sub add <subreddit:sort>  - Subscribe to a subreddit (hot, new, rising, top, best)")
+			fmt.Println("  snoo sub <url>                   - Subscribe to any supported URL (provider is detected)")
+			fmt.Println("  snoo sub add <subreddit:sort>    - Subscribe to a subreddit (hot, new, rising, top, best)")
+			fmt.Println("                                     also u/<user>, <user>/m/<multi>, a+b+c, search:<query>[@<sub>]")
 			fmt.Println("  snoo sub rss <url>               - Subscribe to an RSS feed")
-			fmt.Println("  snoo sub lobsters <category>     - Subscribe to Lobsters (active or recent)")
-			fmt.Println("  snoo sub hn <category>         - Subscribe to HackerNews (top, new, best, ask, show, job)")
+			fmt.Println("  snoo sub lobsters <feed>         - Subscribe to Lobsters (active, recent, newest, t/<tag>, domain/<domain>, ~<user>)")
+			fmt.Println("  snoo sub hn <feed>               - Subscribe to HackerNews (top, new, best, ask, show, job,")
+			fmt.Println("                                     search:<query>, user:<name>, front:<date>)")
 			return
 		}
 
@@ -43,36 +94,47 @@ var subListCmd = &cobra.Command{
 			if src.Description != "" {
 				fmt.Printf("   %s\n", src.Description)
 			}
-			fmt.Printf("   Identifier: %s\n\n", src.Identifier)
+			fmt.Printf("   Identifier: %s\n", src.Identifier)
+			if settings := sourceSettings(src); settings != "" {
+				fmt.Printf("   %s\n", settings)
+			}
+			fmt.Println()
 		}
 	},
 }
 
 var subAddCmd = &cobra.Command{
 	Use:   "add SUBREDDIT[:SORT]",
-	Short: "Subscribe to a subreddit (sort: hot, new, rising, top, best)",
-	Long: `Subscribe to a subreddit with optional sort type.
+	Short: "Subscribe to a subreddit, user, multireddit or search (sort: hot, new, rising, top, best)",
+	Long: `Subscribe to a reddit listing with optional sort type.
 
 Examples:
-  snoo sub add golang          # defaults to 'best'
-  snoo sub add golang:hot      # hot posts
-  snoo sub add golang:new      # new posts
-  snoo sub add golang:rising   # rising posts
-  snoo sub add golang:top      # top posts`,
+  snoo sub add golang                      # defaults to 'best'
+  snoo sub add golang:hot                  # hot posts
+  snoo sub add golang:new                  # new posts
+  snoo sub add golang:rising               # rising posts
+  snoo sub add golang:top                  # top posts
+  snoo sub add golang:top/week             # top posts this week (hour, day, week, month, year, all)
+  snoo sub add golang+rust+zig             # several subreddits in one feed
+  snoo sub add u/spez                      # a user's submissions
+  snoo sub add kn0thing/m/frontpage        # a user's multireddit
+  snoo sub add "search:rust compiler"      # a saved search across reddit
+  snoo sub add "search:generics@golang"    # a saved search within r/golang`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx := cmd.Context()
+		ctx, cancel := fetchContext(cmd.Context())
+		defer cancel()
 		database := db.FromContext(ctx)
 		manager := feed.NewManager(database)
 
 		identifier := args[0]
-		fmt.Printf("Subscribing to r/%s...\n", identifier)
+		fmt.Printf("Subscribing to %s...\n", identifier)
 		if err := manager.Subscribe(ctx, "reddit", identifier); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			return
 		}
 
-		fmt.Printf("Successfully subscribed to r/%s\n", identifier)
+		fmt.Printf("Successfully subscribed to %s\n", identifier)
 	},
 }
 
@@ -81,7 +143,8 @@ var rssAddCmd = &cobra.Command{
 	Short: "Subscribe to an RSS feed",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx := cmd.Context()
+		ctx, cancel := fetchContext(cmd.Context())
+		defer cancel()
 		database := db.FromContext(ctx)
 		manager := feed.NewManager(database)
 
@@ -96,19 +159,25 @@ var rssAddCmd = &cobra.Command{
 }
 
 var lobstersAddCmd = &cobra.Command{
-	Use:   "lobsters CATEGORY",
-	Short: "Subscribe to Lobsters (active or recent)",
-	Args:  cobra.ExactArgs(1),
+	Use:   "lobsters FEED",
+	Short: "Subscribe to Lobsters (active, recent, newest, t/<tag>, domain/<domain>, ~<user>)",
+	Long: `Subscribe to a Lobsters feed.
+
+Examples:
+  snoo sub lobsters active              # front page
+  snoo sub lobsters newest              # newest stories
+  snoo sub lobsters t/go                # stories tagged go
+  snoo sub lobsters t/go,rust           # stories tagged go or rust
+  snoo sub lobsters domain/example.com  # stories linking to example.com
+  snoo sub lobsters ~pushcx             # stories submitted by a user`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx := cmd.Context()
+		ctx, cancel := fetchContext(cmd.Context())
+		defer cancel()
 		database := db.FromContext(ctx)
 		manager := feed.NewManager(database)
 
 		category := args[0]
-		if category != "active" && category != "recent" {
-			fmt.Println("Error: category must be 'active' or 'recent'")
-			return
-		}
 
 		fmt.Printf("Subscribing to Lobsters %s...\n", category)
 		if err := manager.Subscribe(ctx, "lobsters", category); err != nil {
@@ -120,40 +189,169 @@ var lobstersAddCmd = &cobra.Command{
 	},
 }
 
+var hnMinPoints int
+
 var hnAddCmd = &cobra.Command{
-	Use:   "hn CATEGORY",
-	Short: "Subscribe to HackerNews (top, new, best, ask, show, job)",
-	Args:  cobra.ExactArgs(1),
+	Use:   "hn FEED",
+	Short: "Subscribe to HackerNews (top, new, best, ask, show, job, search:<query>, user:<name>, front:<date>)",
+	Long: `Subscribe to a HackerNews feed.
+
+Examples:
+  snoo sub hn top                          # front page
+  snoo sub hn search:"rust compiler"       # stories matching a query
+  snoo sub hn user:pg                      # stories submitted by a user
+  snoo sub hn front:2024-01-15             # the front page of a past day
+  snoo sub hn search:golang --min-points 100`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx := cmd.Context()
+		ctx, cancel := fetchContext(cmd.Context())
+		defer cancel()
 		database := db.FromContext(ctx)
 		manager := feed.NewManager(database)
 
-		category := args[0]
-		validCategories := []string{"top", "new", "best", "ask", "show", "job"}
-		valid := false
-		for _, v := range validCategories {
-			if category == v {
-				valid = true
-				break
+		identifier := args[0]
+		if hnMinPoints > 0 {
+			identifier = fmt.Sprintf("%s;points>=%d", identifier, hnMinPoints)
+		}
+
+		fmt.Printf("Subscribing to HackerNews %s...\n", args[0])
+		if err := manager.Subscribe(ctx, "hackernews", identifier); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("Successfully subscribed to HackerNews %s\n", args[0])
+	},
+}
+
+var sourceAddCmd = &cobra.Command{
+	Use:   "source PROVIDER IDENTIFIER",
+	Short: "Subscribe to a source of any provider, including plugins",
+	Long: `Subscribe to a source by provider type and identifier. This works for
+every registered provider, including external plugins (snoo-provider-<name>
+executables on $PATH or in ~/.local/share/snoo/plugins).
+
+Examples:
+  snoo sub source reddit golang:hot
+  snoo sub source mastodon @gargron@mastodon.social`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := fetchContext(cmd.Context())
+		defer cancel()
+		database := db.FromContext(ctx)
+		manager := feed.NewManager(database)
+
+		providerType, identifier := args[0], args[1]
+		if _, err := feed.Get(providerType); err != nil {
+			types := feed.List()
+			sort.Strings(types)
+			fmt.Printf("Error: %v (available: %s)\n", err, strings.Join(types, ", "))
+			return
+		}
+
+		fmt.Printf("Subscribing to %s (%s)...\n", identifier, providerType)
+		if err := manager.Subscribe(ctx, providerType, identifier); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("Successfully subscribed to %s\n", identifier)
+	},
+}
+
+var subSetCmd = &cobra.Command{
+	Use:   "set ID SETTING VALUE",
+	Short: "Change how a source's posts are shown (weight, pinned, max_posts, group)",
+	Long: `Change how a source's posts are shown in the feed.
+
+Settings:
+  weight      scales the source's posts in the hot sort (default 1)
+  pinned      true to always show the source's posts first
+  max_posts   most posts shown from the source, 0 for no limit
+  group       the group whose tab shows the source, "" for none`,
+	Example: `  snoo sub set 3 weight 2.0
+  snoo sub set 3 pinned true
+  snoo sub set 7 max_posts 10
+  snoo sub set 7 group work`,
+	Args: cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.ParseUint(args[0], 10, 32)
+		if err != nil {
+			fmt.Println("Please provide a numeric ID")
+			return
+		}
+
+		manager := feed.NewManager(db.FromContext(cmd.Context()))
+
+		if args[1] == "group" {
+			if err := manager.SetSourceGroup(uint(id), args[2]); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
 			}
+			fmt.Printf("group = %s\n", args[2])
+			return
 		}
 
-		if !valid {
-			fmt.Println("Error: category must be one of: top, new, best, ask, show, job")
+		column, value, err := parseSourceSetting(args[1], args[2])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
 			return
 		}
 
-		fmt.Printf("Subscribing to HackerNews %s...\n", category)
-		if err := manager.Subscribe(ctx, "hackernews", category); err != nil {
+		if err := manager.UpdateSource(uint(id), column, value); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			return
 		}
 
-		fmt.Printf("Successfully subscribed to HackerNews %s\n", category)
+		fmt.Printf("%s = %v\n", args[1], value)
 	},
 }
 
+// parseSourceSetting checks a setting given to sub set, returning the
+// column it is stored in and its value.
+func parseSourceSetting(setting, value string) (string, any, error) {
+	switch setting {
+	case "weight":
+		w, err := strconv.ParseFloat(value, 64)
+		if err != nil || w < 0 {
+			return "", nil, fmt.Errorf("weight must be a number of at least 0")
+		}
+		return "weight", w, nil
+	case "pinned":
+		pinned, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("pinned must be true or false")
+		}
+		return "pinned", pinned, nil
+	case "max_posts":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return "", nil, fmt.Errorf("max_posts must be a whole number of at least 0")
+		}
+		return "max_posts", n, nil
+	}
+	return "", nil, fmt.Errorf("unknown setting %q (weight, pinned, max_posts, group)", setting)
+}
+
+// sourceSettings describes a source's non-default settings, or returns ""
+// if it has none.
+func sourceSettings(src feed.Source) string {
+	var parts []string
+	if src.Group != "" {
+		parts = append(parts, "group "+src.Group)
+	}
+	if src.Pinned {
+		parts = append(parts, "pinned")
+	}
+	if src.Weight != 1 {
+		parts = append(parts, fmt.Sprintf("weight %g", src.Weight))
+	}
+	if src.MaxPosts > 0 {
+		parts = append(parts, fmt.Sprintf("at most %d posts", src.MaxPosts))
+	}
+	return strings.Join(parts, ", ")
+}
+
 var subRmCmd = &cobra.Command{
 	Use:   "rm ID",
 	Short: "Unsubscribe from a source",
@@ -178,6 +376,8 @@ var subRmCmd = &cobra.Command{
 }
 
 func init() {
+	hnAddCmd.Flags().IntVar(&hnMinPoints, "min-points", 0, "only include stories with at least this many points")
+	subCmd.SuggestionsMinimumDistance = 2
 	rootCmd.AddCommand(subCmd)
-	subCmd.AddCommand(subListCmd, subAddCmd, rssAddCmd, lobstersAddCmd, hnAddCmd, subRmCmd)
+	subCmd.AddCommand(subListCmd, subAddCmd, rssAddCmd, lobstersAddCmd, hnAddCmd, sourceAddCmd, subSetCmd, subRmCmd)
 }
@@ -4,9 +4,8 @@ import (
 	"fmt"
 
 	"github.com/charmbracelet/lipgloss"
-	"github.com/snoofox/snoo/src/db"
+	"github.com/snoofox/snoo/src/config"
 	"github.com/spf13/cobra"
-	"gorm.io/gorm"
 )
 
 type Theme struct {
@@ -137,20 +136,6 @@ func GetCurrentThemeName() string {
 	return currentTheme
 }
 
-func LoadThemeFromDB(gormDB *gorm.DB) {
-	if gormDB == nil {
-		return
-	}
-
-	savedTheme, err := db.GetSetting(gormDB, "theme")
-	if err == nil && savedTheme != "" {
-		if _, ok := themes[savedTheme]; ok {
-			currentTheme = savedTheme
-			applyTheme()
-		}
-	}
-}
-
 func GetThemeNames() []string {
 	names := make([]string, 0, len(themes))
 	for name := range themes {
@@ -204,17 +189,6 @@ var themeCmd = &cobra.Command{
 	Short: "Change the color theme",
 	Long:  "Change the color theme for the feed viewer. Available themes: default, catppuccin, dracula, github, peppermint",
 	Run: func(cmd *cobra.Command, args []string) {
-		// Load current theme from database first
-		gormDB := db.FromContext(cmd.Context())
-		if gormDB != nil {
-			savedTheme, err := db.GetSetting(gormDB, "theme")
-			if err == nil && savedTheme != "" {
-				if _, ok := themes[savedTheme]; ok {
-					currentTheme = savedTheme
-				}
-			}
-		}
-
 		if len(args) == 0 {
 			listThemes()
 			return
@@ -222,8 +196,13 @@ var themeCmd = &cobra.Command{
 
 		themeName := args[0]
 		if SetTheme(themeName) {
-			if gormDB := db.FromContext(cmd.Context()); gormDB != nil {
-				db.SetSetting(gormDB, "theme", themeName)
+			cfg := config.FromContext(cmd.Context())
+			if err := cfg.Update(func(c *config.Config) error {
+				c.Theme = themeName
+				return nil
+			}); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
 			}
 			fmt.Printf("✓ Theme changed to: %s\n", themeName)
 		} else {
@@ -1,7 +1,10 @@
 package cmd
 
+import "time"
+
 type Post struct {
 	ID          string
+	SourceID    uint
 	Title       string
 	Author      string
 	SourceName  string
@@ -10,11 +13,22 @@ type Post struct {
 	URL         string
 	Score       int
 	NumComments int
+	Velocity    float64
+	Hot         float64
 	CreatedUTC  float64
 	Content     string
 	Thumbnail   string
 	NSFW        bool
 	IsRead      bool
+	IsSaved     bool
+	Note        string
+	CommentsURL string
+	Tags        []string
+
+	EnclosureURL      string
+	EnclosureType     string
+	EnclosureLength   int64
+	EnclosureDuration time.Duration
 }
 
 type Comment struct {
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"fmt"
 	"html"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
@@ -24,6 +27,98 @@ func displaySourceName(sourceName string) string {
 	return sourceName
 }
 
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// sparkline draws values as a row of block characters, lowest to highest.
+// Only the most recent sparklineWidth values are shown.
+func sparkline(values []int) string {
+	const blocks = "▁▂▃▄▅▆▇█"
+	const sparklineWidth = 40
+
+	if len(values) > sparklineWidth {
+		values = values[len(values)-sparklineWidth:]
+	}
+
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+
+	levels := []rune(blocks)
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if hi > lo {
+			level = (v - lo) * (len(levels) - 1) / (hi - lo)
+		}
+		b.WriteRune(levels[level])
+	}
+	return b.String()
+}
+
+// formatAge renders a span coarsely, e.g. "45m", "5h" or "3d".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// postAge returns how long ago post was created.
+func postAge(post Post, now time.Time) time.Duration {
+	return now.Sub(time.Unix(int64(post.CreatedUTC), 0))
+}
+
+// parseTimeFlag reads a point in time given on the command line: a span
+// before now such as "36h" or "7d", a date, or an RFC 3339 time.
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	if strings.HasPrefix(value, "-") {
+		return time.Time{}, fmt.Errorf("invalid time %q (spans count back from now, so can't be negative)", value)
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use e.g. 36h, 7d, 2006-01-02 or 2006-01-02T15:04:05Z)", value)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 func wrapText(text string, width int) string {
 	text = html.UnescapeString(text)
 
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snoofox/snoo/src/config"
+	"github.com/spf13/cobra"
+)
+
+// The conditions given to view add.
+var (
+	viewSources  []string
+	viewQuery    string
+	viewMinScore int
+	viewMaxAge   time.Duration
+	viewUnread   bool
+	viewSort     string
+)
+
+var viewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Manage saved views",
+	Long: `Manage saved views. A view shows the posts matching all of its
+conditions, in its own sort. Pick one in the feed with 'v', open the feed
+in one with 'snoo feed --view NAME', or print it with 'snoo list --view NAME'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		viewListCmd.Run(cmd, args)
+	},
+}
+
+var viewListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved views",
+	Run: func(cmd *cobra.Command, args []string) {
+		views := config.FromContext(cmd.Context()).Views
+		if len(views) == 0 {
+			fmt.Println("No saved views")
+			fmt.Println("Create one with: snoo view add <name> --source <source> --min-score 50")
+			return
+		}
+
+		for _, name := range viewNames(views) {
+			fmt.Println(name)
+			fmt.Printf("  %s\n", describeView(views[name]))
+		}
+	},
+}
+
+var viewAddCmd = &cobra.Command{
+	Use:   "add NAME",
+	Short: "Save a view, replacing any of the same name",
+	Example: `  snoo view add rust-high --source r/rust --min-score 50
+  snoo view add catch-up --unread --max-age 24h --sort hot
+  snoo view add releases --query "release" --source lobsters/newest --source HackerNews/top`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		if strings.TrimSpace(name) == "" || strings.Contains(name, ".") {
+			fmt.Println("Error: view names can't be empty or contain dots")
+			return
+		}
+		if viewSort != "" && !validSort(viewSort) {
+			fmt.Printf("Error: unknown sort %q (%s)\n", viewSort, strings.Join(sortKeys(), ", "))
+			return
+		}
+
+		view := config.View{
+			Sources:  viewSources,
+			Query:    viewQuery,
+			MinScore: viewMinScore,
+			MaxAge:   config.Duration{Duration: viewMaxAge},
+			Unread:   viewUnread,
+			Sort:     viewSort,
+		}
+
+		cfg := config.FromContext(cmd.Context())
+		err := cfg.Update(func(c *config.Config) error {
+			if c.Views == nil {
+				c.Views = make(map[string]config.View)
+			}
+			c.Views[name] = view
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("Saved view %s: %s\n", name, describeView(view))
+	},
+}
+
+var viewRmCmd = &cobra.Command{
+	Use:   "rm NAME",
+	Short: "Delete a saved view",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		cfg := config.FromContext(cmd.Context())
+		if _, ok := cfg.Views[name]; !ok {
+			fmt.Printf("Error: view %s does not exist\n", name)
+			return
+		}
+
+		err := cfg.Update(func(c *config.Config) error {
+			delete(c.Views, name)
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Printf("Removed view %s\n", name)
+	},
+}
+
+// viewNames returns the names of views in order.
+func viewNames(views map[string]config.View) []string {
+	names := make([]string, 0, len(views))
+	for name := range views {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// describeView lists a view's conditions.
+func describeView(v config.View) string {
+	var parts []string
+	if len(v.Sources) > 0 {
+		parts = append(parts, "from "+strings.Join(v.Sources, ", "))
+	}
+	if v.Query != "" {
+		parts = append(parts, fmt.Sprintf("matching %q", v.Query))
+	}
+	if v.MinScore != 0 {
+		parts = append(parts, fmt.Sprintf("score at least %d", v.MinScore))
+	}
+	if v.MaxAge.Duration > 0 {
+		parts = append(parts, "newer than "+v.MaxAge.String())
+	}
+	if v.Unread {
+		parts = append(parts, "unread")
+	}
+	if v.Sort != "" {
+		parts = append(parts, "sorted "+v.Sort)
+	}
+	if len(parts) == 0 {
+		return "every post"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// matchesView reports whether post meets every condition of v as of now.
+// Sources match by their full name or by the name shown in the feed.
+func matchesView(v config.View, post Post, now time.Time) bool {
+	if len(v.Sources) > 0 && !slices.ContainsFunc(v.Sources, func(src string) bool {
+		return strings.EqualFold(src, post.SourceName) || strings.EqualFold(src, displaySourceName(post.SourceName))
+	}) {
+		return false
+	}
+	if post.Score < v.MinScore {
+		return false
+	}
+	if v.Unread && post.IsRead {
+		return false
+	}
+	if v.MaxAge.Duration > 0 && postAge(post, now) > v.MaxAge.Duration {
+		return false
+	}
+	return matchesQuery(v.Query, post)
+}
+
+// matchesQuery reports whether every word of query appears in the post's
+// title or text, ignoring case.
+func matchesQuery(query string, post Post) bool {
+	text := strings.ToLower(post.Title + "\n" + post.Content)
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+func init() {
+	viewAddCmd.Flags().StringArrayVar(&viewSources, "source", nil, "only show posts from this source (repeatable)")
+	viewAddCmd.Flags().StringVar(&viewQuery, "query", "", "only show posts whose title or text contains all these words")
+	viewAddCmd.Flags().IntVar(&viewMinScore, "min-score", 0, "only show posts scoring at least this much")
+	viewAddCmd.Flags().DurationVar(&viewMaxAge, "max-age", 0, "only show posts newer than this, e.g. 48h")
+	viewAddCmd.Flags().BoolVar(&viewUnread, "unread", false, "only show unread posts")
+	viewAddCmd.Flags().StringVar(&viewSort, "sort", "", "sort the view's posts (hot, trending, upvotes_desc, newest, ...)")
+
+	viewCmd.AddCommand(viewListCmd, viewAddCmd, viewRmCmd)
+	rootCmd.AddCommand(viewCmd)
+}
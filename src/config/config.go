@@ -0,0 +1,303 @@
+// Package config loads snoo's configuration file. Settings are layered:
+// built-in defaults, then the config file, then SNOO_* environment
+// variables; commands apply their flags on top.
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/snoofox/snoo/src/paths"
+)
+
+type contextKey string
+
+const configKey contextKey = "config"
+
+type Config struct {
+	DBPath string `toml:"db_path"`
+	Theme  string `toml:"theme"`
+
+	Feed      FeedConfig      `toml:"feed"`
+	Refresh   RefreshConfig   `toml:"refresh"`
+	Retention RetentionConfig `toml:"retention"`
+	Ranking   RankingConfig   `toml:"ranking"`
+	Digest    DigestConfig    `toml:"digest"`
+	Network   NetworkConfig   `toml:"network"`
+
+	// Views are saved filters, selectable in the feed and in 'snoo list'.
+	Views map[string]View `toml:"views"`
+
+	// Keys rebinds feed viewer actions, e.g. down = "n".
+	Keys map[string]string `toml:"keys"`
+
+	path    string
+	profile string
+}
+
+// FeedConfig holds the feed's defaults. Sorts, filters and age windows
+// picked in the feed are remembered apart from the config and replace Sort,
+// CommentSort, Sources, Tags and MaxAge while those keep their defaults;
+// once the file or environment changes one, it wins.
+type FeedConfig struct {
+	Sort        string `toml:"sort"`
+	CommentSort string `toml:"comment_sort"`
+	// Sources lists the sources shown in the feed; empty shows all.
+	Sources []string `toml:"sources"`
+	// Tags lists the tags the feed is narrowed to; empty shows all.
+	Tags []string `toml:"tags"`
+	// MaxPerSource caps the posts shown from any one source; zero shows
+	// all. A source's own max_posts overrides it.
+	MaxPerSource int `toml:"max_per_source"`
+	// MaxAge hides posts older than this; zero shows them all.
+	MaxAge Duration `toml:"max_age"`
+}
+
+// View is a saved filter: the posts matching every condition set, in its
+// own sort.
+type View struct {
+	// Sources lists the sources shown; empty shows all.
+	Sources []string `toml:"sources"`
+	// Query lists words that must all appear in a post's title or text.
+	Query    string   `toml:"query"`
+	MinScore int      `toml:"min_score"`
+	MaxAge   Duration `toml:"max_age"`
+	Unread   bool     `toml:"unread"`
+	Sort     string   `toml:"sort"`
+}
+
+type RefreshConfig struct {
+	// Interval is how long fetched posts are served from the cache.
+	Interval Duration `toml:"interval"`
+	// Providers overrides Interval per provider type.
+	Providers map[string]Duration `toml:"providers"`
+}
+
+type RetentionConfig struct {
+	// Days prunes posts no fetch has returned for this many days; zero
+	// keeps them.
+	Days int `toml:"days"`
+	// PerSource keeps only the newest posts of each source; zero keeps all.
+	PerSource int `toml:"per_source"`
+	// SnapshotDays prunes the score history older than this many days,
+	// even of posts that are kept; zero keeps it as long as its post.
+	SnapshotDays int `toml:"snapshot_days"`
+	// Vacuum is how often the database is compacted after pruning; zero
+	// never does.
+	Vacuum Duration `toml:"vacuum"`
+}
+
+type RankingConfig struct {
+	// Gravity is how fast posts sink with age in the hot sort.
+	Gravity float64 `toml:"gravity"`
+	// Weights scales the hot sort per provider type, e.g. lobsters = 1.5.
+	Weights map[string]float64 `toml:"weights"`
+}
+
+type DigestConfig struct {
+	// Format is the digest's format unless --format says otherwise:
+	// markdown, html or text.
+	Format string `toml:"format"`
+	// Top is how many posts each section of the digest lists.
+	Top int `toml:"top"`
+	// Sendmail is the command a mailed digest is piped to, e.g.
+	// "sendmail -t".
+	Sendmail string `toml:"sendmail"`
+	// To is the address a mailed digest is sent to.
+	To string `toml:"to"`
+}
+
+type NetworkConfig struct {
+	// Timeout bounds fetches; zero waits indefinitely, except for articles
+	// opened in the reader, which give up after 15s.
+	Timeout   Duration `toml:"timeout"`
+	UserAgent string   `toml:"user_agent"`
+	Proxy     string   `toml:"proxy"`
+	// Proxies overrides Proxy per provider type.
+	Proxies map[string]string `toml:"proxies"`
+}
+
+// Duration is a time.Duration written as a string such as "1h30m".
+type Duration struct {
+	time.Duration
+}
+
+// String drops the zero units time.Duration prints, so an hour reads "1h"
+// rather than "1h0m0s".
+func (d Duration) String() string {
+	s := d.Duration.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func defaults() *Config {
+	return &Config{
+		Theme: "default",
+		Feed: FeedConfig{
+			Sort:        "upvotes_desc",
+			CommentSort: "best",
+		},
+		Refresh: RefreshConfig{
+			Interval: Duration{time.Hour},
+		},
+		Retention: RetentionConfig{
+			Days:         30,
+			PerSource:    1000,
+			SnapshotDays: 7,
+			Vacuum:       Duration{7 * 24 * time.Hour},
+		},
+		Ranking: RankingConfig{
+			Gravity: 1.8,
+		},
+		Digest: DigestConfig{
+			Format:   "markdown",
+			Top:      5,
+			Sendmail: "sendmail -t",
+		},
+	}
+}
+
+// Path returns the profile's config file: config.toml in its config
+// directory, or $SNOO_CONFIG for the default profile if set.
+func Path(profile string) (string, error) {
+	if path := os.Getenv("SNOO_CONFIG"); path != "" && (profile == "" || profile == paths.DefaultProfile) {
+		return path, nil
+	}
+
+	dir, err := paths.ProfileConfigDir(profile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.toml"), nil
+}
+
+// Load returns the profile's effective configuration: defaults, overlaid
+// with its config file if there is one, overlaid with the environment.
+func Load(profile string) (*Config, error) {
+	c, err := loadFile(profile)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyEnv(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func loadFile(profile string) (*Config, error) {
+	path, err := Path(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	c := defaults()
+	c.path = path
+	c.profile = profile
+
+	if _, err := toml.DecodeFile(path, c); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// File returns the path the configuration was loaded from.
+func (c *Config) File() string {
+	return c.path
+}
+
+// Profile returns the name of the profile the configuration belongs to.
+func (c *Config) Profile() string {
+	if c.profile == "" {
+		return paths.DefaultProfile
+	}
+	return c.profile
+}
+
+// Update changes the config file and the loaded configuration alike. The
+// file is re-read first so that environment overrides are never written
+// out.
+func (c *Config) Update(fn func(*Config) error) error {
+	file, err := loadFile(c.profile)
+	if err != nil {
+		return err
+	}
+	if err := fn(file); err != nil {
+		return err
+	}
+	if err := file.save(); err != nil {
+		return err
+	}
+	return fn(c)
+}
+
+func (c *Config) save() error {
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(c); err != nil {
+		return fmt.Errorf("error encoding config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a failed write can't truncate
+	// the existing config.
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// CopyProfile writes the settings in one profile's config file to
+// another's, except db_path: the copy gets its own database. It returns the
+// source profile's db_path.
+func CopyProfile(from, to string) (string, error) {
+	c, err := loadFile(from)
+	if err != nil {
+		return "", err
+	}
+	dbPath := c.DBPath
+
+	if c.path, err = Path(to); err != nil {
+		return "", err
+	}
+	c.profile = to
+	c.DBPath = ""
+	return dbPath, c.save()
+}
+
+func WithConfig(ctx context.Context, c *Config) context.Context {
+	return context.WithValue(ctx, configKey, c)
+}
+
+func FromContext(ctx context.Context) *Config {
+	return ctx.Value(configKey).(*Config)
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useFile points the default profile at a config file holding content, or
+// at a missing one when content is empty.
+func useFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if content != "" {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+	}
+	t.Setenv("SNOO_CONFIG", path)
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		key  string
+		want string
+	}{
+		{"default", "", nil, "feed.sort", "upvotes_desc"},
+		{"file", "[feed]\nsort = \"new\"\n", nil, "feed.sort", "new"},
+		{"env over default", "", map[string]string{"SNOO_FEED_SORT": "top"}, "feed.sort", "top"},
+		{"env over file", "[feed]\nsort = \"new\"\n", map[string]string{"SNOO_FEED_SORT": "top"}, "feed.sort", "top"},
+		{"file keeps other defaults", "[feed]\nsort = \"new\"\n", nil, "feed.comment_sort", "best"},
+		{"duration", "[network]\ntimeout = \"30s\"\n", map[string]string{"SNOO_NETWORK_TIMEOUT": "10s"}, "network.timeout", "10s"},
+		{"list", "", map[string]string{"SNOO_FEED_SOURCES": "r/golang, hn/top"}, "feed.sources", "r/golang,hn/top"},
+		{"alias", "", map[string]string{"SNOO_USER_AGENT": "agent/1"}, "network.user_agent", "agent/1"},
+		{"name over alias", "", map[string]string{"SNOO_USER_AGENT": "agent/1", "SNOO_NETWORK_USER_AGENT": "agent/2"}, "network.user_agent", "agent/2"},
+		{"map from file", "[ranking.weights]\nlobsters = 1.5\n", nil, "ranking.weights.lobsters", "1.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFile(t, tt.file)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			c, err := Load("")
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if got, err := c.Get(tt.key); err != nil || got != tt.want {
+				t.Errorf("Get(%q) = %q, %v; want %q", tt.key, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		want string
+	}{
+		{"bad file", "[feed\n", nil, "error reading"},
+		{"bad env", "", map[string]string{"SNOO_RETENTION_DAYS": "many"}, "SNOO_RETENTION_DAYS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFile(t, tt.file)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			if _, err := Load(""); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateLeavesEnvOutOfFile(t *testing.T) {
+	path := useFile(t, "")
+	t.Setenv("SNOO_THEME", "dracula")
+
+	c, err := Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := c.Update(func(c *Config) error { return c.Set("feed.sort", "new") }); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if c.Theme != "dracula" || c.Feed.Sort != "new" {
+		t.Errorf("loaded config has theme %q, sort %q; want dracula, new", c.Theme, c.Feed.Sort)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	if strings.Contains(string(data), "dracula") {
+		t.Errorf("the environment's theme was written to the file:\n%s", data)
+	}
+	if !strings.Contains(string(data), `sort = "new"`) {
+		t.Errorf("the change is missing from the file:\n%s", data)
+	}
+}
@@ -0,0 +1,275 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(Duration{})
+
+// Keys returns every settable key in dotted form, e.g. network.proxy. Map
+// settings are listed by their table name; set them as table.entry.
+func Keys() []string {
+	var keys []string
+	walk(reflect.ValueOf(defaults()).Elem(), "", func(key string, _ reflect.Value) {
+		keys = append(keys, key)
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// Get returns the value of a dotted key as text.
+func (c *Config) Get(key string) (string, error) {
+	v, entry, err := lookup(c, key)
+	if err != nil {
+		return "", err
+	}
+
+	if entry != "" {
+		elem := v.MapIndex(reflect.ValueOf(entry))
+		if !elem.IsValid() {
+			return "", nil
+		}
+		return format(elem), nil
+	}
+	return format(v), nil
+}
+
+// IsDefault reports whether a dotted key holds its built-in default, i.e.
+// neither the config file nor the environment changes it.
+func (c *Config) IsDefault(key string) bool {
+	value, err := c.Get(key)
+	if err != nil {
+		return false
+	}
+	def, _ := defaults().Get(key)
+	return value == def
+}
+
+// Set parses value and assigns it to a dotted key. An empty value clears
+// a map entry.
+func (c *Config) Set(key, value string) error {
+	v, entry, err := lookup(c, key)
+	if err != nil {
+		return err
+	}
+
+	if entry == "" {
+		return parse(v, value)
+	}
+
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	if value == "" {
+		v.SetMapIndex(reflect.ValueOf(entry), reflect.Value{})
+		return nil
+	}
+	elem := reflect.New(v.Type().Elem()).Elem()
+	if err := parse(elem, value); err != nil {
+		return err
+	}
+	v.SetMapIndex(reflect.ValueOf(entry), elem)
+	return nil
+}
+
+// lookup finds the field for key. For map settings, entry is the map key
+// following the table name.
+func lookup(c *Config, key string) (v reflect.Value, entry string, err error) {
+	v = reflect.ValueOf(c).Elem()
+	parts := strings.Split(key, ".")
+
+	for i, part := range parts {
+		if v.Kind() == reflect.Map {
+			if i != len(parts)-1 {
+				break
+			}
+			return v, part, nil
+		}
+		if v.Kind() != reflect.Struct || v.Type() == durationType {
+			break
+		}
+
+		field, ok := fieldByTag(v, part)
+		if !ok {
+			break
+		}
+		v = field
+
+		if i == len(parts)-1 {
+			if v.Kind() == reflect.Struct && v.Type() != durationType {
+				break
+			}
+			return v, "", nil
+		}
+	}
+	return reflect.Value{}, "", fmt.Errorf("unknown config key %q (run 'snoo config get' to list keys)", key)
+}
+
+func fieldByTag(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if tag, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ","); tag == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// walk calls fn for each setting below v, recursing into sections.
+func walk(v reflect.Value, prefix string, fn func(key string, v reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		key := prefix + tag
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct && field.Type() != durationType {
+			walk(field, key+".", fn)
+			continue
+		}
+		fn(key, field)
+	}
+}
+
+func format(v reflect.Value) string {
+	if v.Type() == durationType {
+		return v.Interface().(Duration).String()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = format(v.Index(i))
+		}
+		return strings.Join(items, ",")
+	case reflect.Map:
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			keys[i] = k + "=" + format(v.MapIndex(reflect.ValueOf(k)))
+		}
+		return strings.Join(keys, " ")
+	case reflect.Struct:
+		var fields []string
+		walk(v, "", func(key string, field reflect.Value) {
+			if !field.IsZero() {
+				fields = append(fields, key+":"+format(field))
+			}
+		})
+		return "{" + strings.Join(fields, " ") + "}"
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func parse(v reflect.Value, value string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		v.Set(reflect.ValueOf(Duration{d}))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		items := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := parse(elem, item); err != nil {
+				return err
+			}
+			items = reflect.Append(items, elem)
+		}
+		v.Set(items)
+	case reflect.Map:
+		return fmt.Errorf("set a single entry, e.g. <key>.<name>")
+	case reflect.Struct:
+		return fmt.Errorf("edit this setting with 'snoo config edit'")
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// envAliases are short or historical environment variable names for
+// settings, applied before the SNOO_<KEY> names.
+var envAliases = map[string]string{
+	"SNOO_DB":         "db_path",
+	"SNOO_USER_AGENT": "network.user_agent",
+}
+
+// EnvName is the environment variable that overrides key.
+func EnvName(key string) string {
+	return "SNOO_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// applyEnv overrides settings from SNOO_* variables. Map settings can only
+// be set in the file.
+func applyEnv(c *Config) error {
+	for name, key := range envAliases {
+		if value, ok := os.LookupEnv(name); ok {
+			if err := c.Set(key, value); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+
+	var err error
+	walk(reflect.ValueOf(c).Elem(), "", func(key string, v reflect.Value) {
+		if err != nil || v.Kind() == reflect.Map {
+			return
+		}
+		name := EnvName(key)
+		if value, ok := os.LookupEnv(name); ok {
+			if perr := parse(v, value); perr != nil {
+				err = fmt.Errorf("%s: %w", name, perr)
+			}
+		}
+	})
+	return err
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSetGet(t *testing.T) {
+	tests := []struct {
+		key, value string
+		want       string
+		ok         bool
+	}{
+		{"theme", "dracula", "dracula", true},
+		{"feed.max_per_source", "5", "5", true},
+		{"feed.max_per_source", "five", "", false},
+		{"feed.tags", " go, ,rust ", "go,rust", true},
+		{"feed.tags", "", "", true},
+		{"ranking.gravity", "1.5", "1.5", true},
+		{"retention.vacuum", "24h", "24h", true},
+		{"retention.vacuum", "daily", "", false},
+		{"network.proxies.reddit", "socks5h://127.0.0.1:9050", "socks5h://127.0.0.1:9050", true},
+		{"refresh.providers.hackernews", "10m", "10m", true},
+		{"keys.down", "n", "n", true},
+		{"keys", "down=n", "", false},
+		{"network", "x", "", false},
+		{"network.timeout.seconds", "1", "", false},
+		{"nope", "x", "", false},
+	}
+
+	for _, tt := range tests {
+		c := defaults()
+		err := c.Set(tt.key, tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("Set(%q, %q) error = %v, want ok %v", tt.key, tt.value, err, tt.ok)
+			continue
+		}
+		if !tt.ok {
+			continue
+		}
+		if got, err := c.Get(tt.key); err != nil || got != tt.want {
+			t.Errorf("Set(%q, %q), then Get = %q, %v; want %q", tt.key, tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestSetClearsMapEntry(t *testing.T) {
+	c := defaults()
+	for _, value := range []string{"n", ""} {
+		if err := c.Set("keys.down", value); err != nil {
+			t.Fatalf("Set(keys.down, %q): %v", value, err)
+		}
+	}
+	if _, ok := c.Keys["down"]; ok {
+		t.Errorf("keys.down is still set: %v", c.Keys)
+	}
+}
+
+func TestIsDefault(t *testing.T) {
+	tests := []struct {
+		key, value string
+		want       bool
+	}{
+		{"feed.sort", "", false},
+		{"feed.sort", "upvotes_desc", true},
+		{"feed.sort", "new", false},
+		{"feed.tags", "", true},
+		{"feed.tags", "go", false},
+		{"network.timeout", "0s", true},
+		{"keys.down", "n", false},
+	}
+
+	for _, tt := range tests {
+		c := defaults()
+		if err := c.Set(tt.key, tt.value); err != nil {
+			t.Fatalf("Set(%q, %q): %v", tt.key, tt.value, err)
+		}
+		if got := c.IsDefault(tt.key); got != tt.want {
+			t.Errorf("after Set(%q, %q), IsDefault = %v, want %v", tt.key, tt.value, got, tt.want)
+		}
+	}
+
+	if defaults().IsDefault("nope") {
+		t.Error("IsDefault(nope) = true for an unknown key")
+	}
+}
+
+func TestKeys(t *testing.T) {
+	keys := Keys()
+	for _, key := range []string{"theme", "feed.sort", "network.timeout", "network.proxies", "keys"} {
+		if !slices.Contains(keys, key) {
+			t.Errorf("Keys() lacks %q", key)
+		}
+		if EnvName(key) == "" {
+			t.Errorf("EnvName(%q) is empty", key)
+		}
+	}
+	if got := EnvName("network.user_agent"); got != "SNOO_NETWORK_USER_AGENT" {
+		t.Errorf("EnvName(network.user_agent) = %q", got)
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/snoofox/snoo/src/db"
+	"gorm.io/gorm"
+)
+
+// legacySettings maps the keys older versions used in the database's
+// settings table to config keys. Per-provider proxies were kept as
+// proxy.<provider>.
+var legacySettings = map[string]string{
+	"theme":        "theme",
+	"feed_sort":    "feed.sort",
+	"comment_sort": "feed.comment_sort",
+	"feed_sources": "feed.sources",
+	"feed_tags":    "feed.tags",
+	"proxy":        "network.proxy",
+}
+
+// MigrateSettings moves preferences that older versions kept in the
+// database's settings table into the config file and deletes them from the
+// database. Settings the config file already changes from their defaults
+// are kept as they are. Other rows, such as when the database was last
+// vacuumed, stay in the database.
+func MigrateSettings(c *Config, database *gorm.DB) error {
+	keys := make([]string, 0, len(legacySettings))
+	for key := range legacySettings {
+		keys = append(keys, key)
+	}
+
+	var settings []db.Setting
+	if err := database.Where("key IN ? OR key LIKE 'proxy.%'", keys).Find(&settings).Error; err != nil {
+		return err
+	}
+	if len(settings) == 0 {
+		return nil
+	}
+
+	err := c.Update(func(c *Config) error {
+		for _, s := range settings {
+			if key := legacyKey(s.Key); c.IsDefault(key) {
+				if err := c.Set(key, s.Value); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Re-apply the environment on top of the migrated values.
+	fresh, err := Load(c.profile)
+	if err != nil {
+		return err
+	}
+	*c = *fresh
+
+	return db.Write(database, func(tx *gorm.DB) error {
+		for _, s := range settings {
+			if err := tx.Unscoped().Delete(&s).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// legacyKey returns the config key of a setting older versions kept in the
+// database.
+func legacyKey(legacy string) string {
+	if provider, ok := strings.CutPrefix(legacy, "proxy."); ok {
+		return "network.proxies." + provider
+	}
+	return legacySettings[legacy]
+}
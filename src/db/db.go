@@ -2,9 +2,12 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/snoofox/snoo/src/paths"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -14,36 +17,59 @@ type contextKey string
 
 const dbKey contextKey = "db"
 
-func GetDB() (*gorm.DB, error) {
-	dbPath, err := getDBPath()
-	if err != nil {
+const busyTimeout = 5 * time.Second
+
+// Open opens the database at dbPath, or at data.sqlite3 in the data
+// directory when dbPath is empty. Call Migrate before using it, and make
+// changes through Write.
+//
+// The database uses WAL so readers don't block the writer, waits up to
+// busyTimeout for a lock held by another process, and takes the write lock
+// when a transaction begins rather than failing to upgrade to it later.
+func Open(dbPath string) (*gorm.DB, error) {
+	if dbPath == "" {
+		var err error
+		if dbPath, err = getDBPath(); err != nil {
+			return nil, err
+		}
+	} else if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
 		return nil, err
 	}
 
-	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+	dsn := fmt.Sprintf("%s?_journal_mode=WAL&_busy_timeout=%d&_txlock=immediate", dbPath, busyTimeout.Milliseconds())
+	return gorm.Open(sqlite.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
+}
+
+// CopyTo writes a consistent copy of the database at src to a new file at
+// dst.
+func CopyTo(src, dst string) error {
+	database, err := Open(src)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	db.AutoMigrate(&Source{}, &Post{}, &Comment{}, &Setting{})
+	sqlDB, err := database.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
 
-	return db, nil
+	return vacuumInto(database, dst)
 }
 
 func getDBPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	dataDir, err := paths.DataDir()
 	if err != nil {
 		return "", err
 	}
 
-	snooDir := filepath.Join(homeDir, ".snoo")
-	if err := os.MkdirAll(snooDir, 0755); err != nil {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return "", err
 	}
 
-	return filepath.Join(snooDir, "data.sqlite3"), nil
+	return filepath.Join(dataDir, "data.sqlite3"), nil
 }
 
 func WithDB(ctx context.Context, db *gorm.DB) context.Context {
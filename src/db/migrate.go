@@ -0,0 +1,348 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchemaVersion records one applied migration.
+type SchemaVersion struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migration is one step in the schema's history. Migrations run in order
+// of Version, each in its own transaction, and are never edited once
+// released; change the schema by appending a new one.
+//
+// The first migration creates the schema as it was before migrations
+// existed, and later migrations change it from there. Each works on its own
+// copies of the models as of its version, never on the live ones, so that
+// changing a model can't change what a released migration does.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+}
+
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create schema",
+		Up:      createSchema,
+	},
+	{
+		Version: 2,
+		Name:    "unique posts per source",
+		Up: func(tx *gorm.DB) error {
+			type Post struct {
+				SourceID   uint   `gorm:"uniqueIndex:idx_posts_source_external"`
+				ExternalID string `gorm:"size:512;uniqueIndex:idx_posts_source_external"`
+			}
+			if err := dedupePosts(tx); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&Post{}, "idx_posts_source_external")
+		},
+	},
+	{
+		Version: 3,
+		Name:    "saved and annotated posts",
+		Up: func(tx *gorm.DB) error {
+			type Post struct {
+				SavedAt *time.Time `gorm:"index"`
+				Note    string     `gorm:"type:text;not null;default:''"`
+			}
+			for _, field := range []string{"SavedAt", "Note"} {
+				if err := tx.Migrator().AddColumn(&Post{}, field); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().CreateIndex(&Post{}, "SavedAt")
+		},
+	},
+	{
+		Version: 4,
+		Name:    "post snapshots",
+		Up: func(tx *gorm.DB) error {
+			type PostSnapshot struct {
+				ID          uint `gorm:"primaryKey"`
+				PostID      uint `gorm:"index:idx_post_snapshots_post_taken,priority:1"`
+				Score       int
+				NumComments int
+				TakenAt     time.Time `gorm:"index:idx_post_snapshots_post_taken,priority:2;index"`
+			}
+			return tx.Migrator().CreateTable(&PostSnapshot{})
+		},
+	},
+	{
+		Version: 5,
+		Name:    "source weight, pinning and caps",
+		Up: func(tx *gorm.DB) error {
+			type Source struct {
+				Weight   float64 `gorm:"not null;default:1"`
+				Pinned   bool    `gorm:"not null;default:false"`
+				MaxPosts int     `gorm:"not null;default:0"`
+			}
+			for _, field := range []string{"Weight", "Pinned", "MaxPosts"} {
+				if err := tx.Migrator().AddColumn(&Source{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 6,
+		Name:    "source groups",
+		Up: func(tx *gorm.DB) error {
+			type Group struct {
+				gorm.Model
+				Name string `gorm:"size:64;uniqueIndex"`
+			}
+			type Source struct {
+				GroupID *uint `gorm:"index"`
+			}
+			if err := tx.Migrator().CreateTable(&Group{}); err != nil {
+				return err
+			}
+			if err := tx.Migrator().AddColumn(&Source{}, "GroupID"); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&Source{}, "GroupID")
+		},
+	},
+	{
+		Version: 7,
+		Name:    "source metadata",
+		Up: func(tx *gorm.DB) error {
+			type Source struct {
+				Metadata string `gorm:"type:text"`
+			}
+			return tx.Migrator().AddColumn(&Source{}, "Metadata")
+		},
+	},
+}
+
+// createSchema brings a new database, or one from a version that predates
+// migrations, to the schema those versions kept up to date with
+// AutoMigrate. The models are copies as of then and must not change.
+func createSchema(tx *gorm.DB) error {
+	type Source struct {
+		gorm.Model
+		Type        string `gorm:"size:32;index"`
+		Identifier  string `gorm:"size:512;index"`
+		Name        string `gorm:"size:256"`
+		DisplayName string `gorm:"size:256"`
+		Description string `gorm:"type:text"`
+		IconURL     string `gorm:"size:512"`
+		LastFetchAt *time.Time
+	}
+
+	type Post struct {
+		gorm.Model
+		SourceID          uint `gorm:"index"`
+		Source            Source
+		SourceType        string `gorm:"size:32;index"`
+		ExternalID        string `gorm:"size:512;index"`
+		Title             string `gorm:"type:text"`
+		Author            string `gorm:"size:128"`
+		SourceName        string `gorm:"size:256;index"`
+		Permalink         string `gorm:"size:512"`
+		URL               string `gorm:"type:text"`
+		Score             int
+		NumComments       int
+		CreatedUTC        float64 `gorm:"index"`
+		Content           string  `gorm:"type:text"`
+		Thumbnail         string  `gorm:"size:512"`
+		NSFW              bool
+		Tags              string `gorm:"size:256"`
+		CommentsURL       string `gorm:"type:text"`
+		CommentsFetchAt   *time.Time
+		ReadAt            *time.Time `gorm:"index"`
+		EnclosureURL      string     `gorm:"type:text"`
+		EnclosureType     string     `gorm:"size:128"`
+		EnclosureLength   int64
+		EnclosureDuration int
+	}
+
+	type Comment struct {
+		gorm.Model
+		RedditID   string `gorm:"uniqueIndex;size:12"`
+		PostID     uint   `gorm:"index"`
+		Post       Post
+		ParentID   *uint
+		Author     string `gorm:"size:64"`
+		Body       string `gorm:"type:text"`
+		Score      int
+		CreatedUTC float64
+		Depth      int
+	}
+
+	type Setting struct {
+		gorm.Model
+		Key   string `gorm:"uniqueIndex;size:64"`
+		Value string `gorm:"size:256"`
+	}
+
+	return tx.AutoMigrate(&Source{}, &Post{}, &Comment{}, &Setting{})
+}
+
+// dedupePosts removes posts stored more than once for the same source,
+// keeping the oldest copy, marking it read if any copy was and moving the
+// other copies' comments to it.
+func dedupePosts(tx *gorm.DB) error {
+	steps := []string{
+		`UPDATE posts SET read_at = (
+			SELECT MAX(dup.read_at) FROM posts dup
+			WHERE dup.source_id = posts.source_id AND dup.external_id = posts.external_id
+		) WHERE read_at IS NULL AND id IN (
+			SELECT MIN(id) FROM posts GROUP BY source_id, external_id HAVING COUNT(*) > 1
+		)`,
+		`UPDATE comments SET post_id = (
+			SELECT MIN(dup.id) FROM posts p
+			JOIN posts dup ON dup.source_id = p.source_id AND dup.external_id = p.external_id
+			WHERE p.id = comments.post_id
+		) WHERE post_id IN (
+			SELECT id FROM posts WHERE id NOT IN (
+				SELECT MIN(id) FROM posts GROUP BY source_id, external_id
+			)
+		)`,
+		`DELETE FROM posts WHERE id NOT IN (
+			SELECT MIN(id) FROM posts GROUP BY source_id, external_id
+		)`,
+	}
+	for _, sql := range steps {
+		if err := tx.Exec(sql).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Migrations returns every known migration in order.
+func Migrations() []Migration {
+	return migrations
+}
+
+// Applied returns the migrations recorded in the database, oldest first.
+func Applied(database *gorm.DB) ([]SchemaVersion, error) {
+	if err := database.AutoMigrate(&SchemaVersion{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_versions table: %w", err)
+	}
+
+	var applied []SchemaVersion
+	if err := database.Order("version").Find(&applied).Error; err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// Pending returns the migrations not yet applied to the database.
+func Pending(database *gorm.DB) ([]Migration, error) {
+	applied, err := Applied(database)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(map[int]bool, len(applied))
+	for _, v := range applied {
+		done[v.Version] = true
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if !done[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies pending migrations. If the database already holds data
+// it is backed up first; the backup's path is returned, or "" if none was
+// made.
+func Migrate(database *gorm.DB) (applied []Migration, backup string, err error) {
+	pending, err := Pending(database)
+	if err != nil || len(pending) == 0 {
+		return nil, "", err
+	}
+
+	if hasData(database) {
+		if backup, err = backupBefore(database, pending[0].Version); err != nil {
+			return nil, "", fmt.Errorf("failed to back up database before migrating: %w", err)
+		}
+	}
+
+	for _, m := range pending {
+		err := database.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaVersion{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			if backup != "" {
+				return applied, backup, fmt.Errorf("migration %d (%s) failed, backup at %s: %w", m.Version, m.Name, backup, err)
+			}
+			return applied, backup, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		applied = append(applied, m)
+	}
+
+	return applied, backup, nil
+}
+
+// hasData reports whether the database has tables besides the migration
+// bookkeeping, i.e. whether it is worth backing up.
+func hasData(database *gorm.DB) bool {
+	var count int64
+	database.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name NOT IN ('schema_versions', 'sqlite_sequence')").Scan(&count)
+	return count > 0
+}
+
+// backupBefore copies the database next to itself, named after the first
+// migration about to run, e.g. data.sqlite3.pre-v2-20250101-120000.bak.
+func backupBefore(database *gorm.DB, version int) (string, error) {
+	file, err := fileName(database)
+	if err != nil {
+		return "", err
+	}
+
+	backup := fmt.Sprintf("%s.pre-v%d-%s.bak", file, version, time.Now().Format("20060102-150405"))
+	if err := vacuumInto(database, backup); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
+// fileName returns the file backing the database's main schema.
+func fileName(database *gorm.DB) (string, error) {
+	var rows []struct {
+		Name string
+		File string
+	}
+	if err := database.Raw("PRAGMA database_list").Scan(&rows).Error; err != nil {
+		return "", err
+	}
+	for _, row := range rows {
+		if row.Name == "main" && row.File != "" {
+			return row.File, nil
+		}
+	}
+	return "", fmt.Errorf("database has no backing file")
+}
+
+// vacuumInto writes a consistent copy of the database to dst, which must
+// not exist yet.
+func vacuumInto(database *gorm.DB, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return database.Exec("VACUUM INTO ?", dst).Error
+}
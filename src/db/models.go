@@ -16,14 +16,29 @@ type Source struct {
 	Description string `gorm:"type:text"`
 	IconURL     string `gorm:"size:512"`
 	LastFetchAt *time.Time
+
+	Weight   float64 `gorm:"not null;default:1"`     // scales the source in the hot sort
+	Pinned   bool    `gorm:"not null;default:false"` // its posts come first
+	MaxPosts int     `gorm:"not null;default:0"`     // most posts shown at once, 0 for no limit
+
+	GroupID *uint `gorm:"index"`
+	Group   *Group
+
+	Metadata map[string]interface{} `gorm:"type:text;serializer:json"` // kept for the source's provider
+}
+
+// Group is a named set of sources, shown as a tab in the feed.
+type Group struct {
+	gorm.Model
+	Name string `gorm:"size:64;uniqueIndex"`
 }
 
 type Post struct {
 	gorm.Model
-	SourceID        uint `gorm:"index"`
+	SourceID        uint `gorm:"index;uniqueIndex:idx_posts_source_external"`
 	Source          Source
 	SourceType      string `gorm:"size:32;index"`
-	ExternalID      string `gorm:"size:512;index"` // Reddit ID, RSS GUID, etc.
+	ExternalID      string `gorm:"size:512;index;uniqueIndex:idx_posts_source_external"` // Reddit ID, RSS GUID, etc.
 	Title           string `gorm:"type:text"`
 	Author          string `gorm:"size:128"`
 	SourceName      string `gorm:"size:256;index"`
@@ -35,8 +50,17 @@ type Post struct {
 	Content         string  `gorm:"type:text"`
 	Thumbnail       string  `gorm:"size:512"`
 	NSFW            bool
+	Tags            string `gorm:"size:256"` // comma separated
+	CommentsURL     string `gorm:"type:text"`
 	CommentsFetchAt *time.Time
 	ReadAt          *time.Time `gorm:"index"`
+	SavedAt         *time.Time `gorm:"index"`
+	Note            string     `gorm:"type:text;not null;default:''"`
+
+	EnclosureURL      string `gorm:"type:text"`
+	EnclosureType     string `gorm:"size:128"`
+	EnclosureLength   int64
+	EnclosureDuration int // seconds
 }
 
 type Comment struct {
@@ -52,6 +76,16 @@ type Comment struct {
 	Depth      int
 }
 
+// PostSnapshot records a post's score and comment count as of the fetch
+// that found them changed. A post keeps them until its next snapshot.
+type PostSnapshot struct {
+	ID          uint `gorm:"primaryKey"`
+	PostID      uint `gorm:"index:idx_post_snapshots_post_taken,priority:1"`
+	Score       int
+	NumComments int
+	TakenAt     time.Time `gorm:"index:idx_post_snapshots_post_taken,priority:2;index"`
+}
+
 type Setting struct {
 	gorm.Model
 	Key   string `gorm:"uniqueIndex;size:64"`
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// upsertColumns are the post columns a fetch refreshes. Everything else,
+// such as read_at, created_at and deleted_at, belongs to the reader and
+// survives, so fetching a deleted post again doesn't bring it back.
+var upsertColumns = []string{
+	"updated_at",
+	"source_type", "title", "author", "source_name", "permalink", "url",
+	"score", "num_comments", "created_utc", "content", "thumbnail", "nsfw",
+	"tags", "comments_url",
+	"enclosure_url", "enclosure_type", "enclosure_length", "enclosure_duration",
+}
+
+const upsertBatchSize = 100
+
+// UpsertPosts stores the posts fetched from one source in a single
+// transaction, inserting new ones, refreshing those already stored and
+// recording a snapshot of each one whose score or comment count moved since
+// its last snapshot, unless none has a score, as with RSS.
+// It reports how many were new and how many were updated. Posts must carry
+// sourceID; later duplicates of an external ID win.
+func UpsertPosts(database *gorm.DB, sourceID uint, posts []Post) (created, updated int, err error) {
+	if len(posts) == 0 {
+		return 0, 0, nil
+	}
+
+	unique := make([]Post, 0, len(posts))
+	index := make(map[string]int, len(posts))
+	for _, p := range posts {
+		if i, ok := index[p.ExternalID]; ok {
+			unique[i] = p
+			continue
+		}
+		index[p.ExternalID] = len(unique)
+		unique = append(unique, p)
+	}
+
+	ids := make([]string, len(unique))
+	scored := false
+	for i, p := range unique {
+		ids[i] = p.ExternalID
+		scored = scored || p.Score != 0
+	}
+
+	err = Write(database, func(tx *gorm.DB) error {
+		var existing int64
+		for start := 0; start < len(ids); start += upsertBatchSize {
+			end := min(start+upsertBatchSize, len(ids))
+
+			var n int64
+			if err := tx.Unscoped().Model(&Post{}).
+				Where("source_id = ? AND external_id IN ?", sourceID, ids[start:end]).
+				Count(&n).Error; err != nil {
+				return err
+			}
+			existing += n
+		}
+
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "source_id"}, {Name: "external_id"}},
+			DoUpdates: clause.AssignmentColumns(upsertColumns),
+		}).CreateInBatches(&unique, upsertBatchSize).Error
+		if err != nil {
+			return err
+		}
+
+		if scored {
+			now := time.Now()
+			for start := 0; start < len(ids); start += upsertBatchSize {
+				end := min(start+upsertBatchSize, len(ids))
+
+				err := tx.Exec(`INSERT INTO post_snapshots (post_id, score, num_comments, taken_at)
+					SELECT p.id, p.score, p.num_comments, ? FROM posts p
+					WHERE p.source_id = ? AND p.external_id IN ?
+					AND NOT EXISTS (
+						SELECT 1 FROM post_snapshots s
+						WHERE s.id = (SELECT MAX(id) FROM post_snapshots WHERE post_id = p.id)
+						AND s.score = p.score AND s.num_comments = p.num_comments
+					)`,
+					now, sourceID, ids[start:end]).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		updated = int(existing)
+		created = len(unique) - updated
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return created, updated, nil
+}
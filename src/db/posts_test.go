@@ -0,0 +1,69 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestUpsertPosts(t *testing.T) {
+	database := openTestDB(t, filepath.Join(t.TempDir(), "data.sqlite3"))
+	if _, _, err := Migrate(database); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	source := Source{Type: "test", Identifier: "source"}
+	if err := Write(database, func(tx *gorm.DB) error { return tx.Create(&source).Error }); err != nil {
+		t.Fatalf("create source: %v", err)
+	}
+
+	post := func(id, title string) Post {
+		return Post{SourceID: source.ID, SourceType: "test", ExternalID: id, Title: title}
+	}
+
+	created, updated, err := UpsertPosts(database, source.ID, []Post{post("1", "one"), post("2", "two"), post("2", "two again")})
+	if err != nil {
+		t.Fatalf("UpsertPosts: %v", err)
+	}
+	if created != 2 || updated != 0 {
+		t.Errorf("first fetch: created %d, updated %d; want 2, 0", created, updated)
+	}
+
+	// What the reader did with a post survives the next fetch.
+	readAt := time.Now()
+	if err := database.Model(&Post{}).Where("external_id = ?", "1").Update("read_at", readAt).Error; err != nil {
+		t.Fatalf("mark read: %v", err)
+	}
+	if err := database.Where("external_id = ?", "2").Delete(&Post{}).Error; err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	created, updated, err = UpsertPosts(database, source.ID, []Post{post("1", "one, edited"), post("2", "two"), post("3", "three")})
+	if err != nil {
+		t.Fatalf("UpsertPosts: %v", err)
+	}
+	if created != 1 || updated != 2 {
+		t.Errorf("second fetch: created %d, updated %d; want 1, 2", created, updated)
+	}
+
+	var one Post
+	if err := database.Where("external_id = ?", "1").First(&one).Error; err != nil {
+		t.Fatalf("load post: %v", err)
+	}
+	if one.Title != "one, edited" {
+		t.Errorf("title is %q, want the fetched one", one.Title)
+	}
+	if one.ReadAt == nil {
+		t.Error("read_at was cleared by the fetch")
+	}
+
+	var visible int64
+	if err := database.Model(&Post{}).Count(&visible).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if visible != 2 {
+		t.Errorf("got %d visible posts, want 2: a deleted post came back", visible)
+	}
+}
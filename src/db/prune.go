@@ -0,0 +1,123 @@
+package db
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lastVacuumKey is the setting recording when the database was last
+// vacuumed.
+const lastVacuumKey = "last_vacuum"
+
+// Retention decides which cached posts are kept. Saved posts and posts
+// with a note are kept regardless; posts whose source is gone are not.
+type Retention struct {
+	// MaxAge prunes posts no fetch has returned for this long. Posts still
+	// in a source's feed are refreshed on every fetch, so they stay.
+	MaxAge time.Duration
+	// PerSource keeps only the newest posts of each source.
+	PerSource int
+	// SnapshotAge prunes score snapshots taken longer ago than this, even
+	// of posts that are kept.
+	SnapshotAge time.Duration
+}
+
+// prunable selects the posts r would delete as of now.
+func prunable(tx *gorm.DB, r Retention, now time.Time) *gorm.DB {
+	conds := []string{"source_id NOT IN (SELECT id FROM sources)"}
+	var args []any
+
+	if r.MaxAge > 0 {
+		conds = append(conds, "updated_at < ?")
+		args = append(args, now.Add(-r.MaxAge))
+	}
+	if r.PerSource > 0 {
+		conds = append(conds, `id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY source_id ORDER BY created_utc DESC, id DESC) AS n
+				FROM posts
+			) WHERE n > ?
+		)`)
+		args = append(args, r.PerSource)
+	}
+
+	return tx.Unscoped().Model(&Post{}).
+		Where("saved_at IS NULL AND note = ''").
+		Where("("+strings.Join(conds, " OR ")+")", args...)
+}
+
+// PruneCount is how many posts of one source a prune removes.
+type PruneCount struct {
+	SourceName string
+	Count      int64
+}
+
+// Prunable reports, per source, how many posts Prune would delete.
+func Prunable(database *gorm.DB, r Retention, now time.Time) ([]PruneCount, error) {
+	var counts []PruneCount
+	err := prunable(database, r, now).
+		Select("source_name, COUNT(*) AS count").
+		Group("source_name").
+		Order("count DESC").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// Prune deletes the posts r doesn't keep and returns how many it deleted.
+// Comments and snapshots of deleted posts, and snapshots older than
+// r.SnapshotAge, are deleted too, except each post's latest, which holds
+// its score until it changes.
+func Prune(database *gorm.DB, r Retention, now time.Time) (int64, error) {
+	var deleted int64
+	err := Write(database, func(tx *gorm.DB) error {
+		result := prunable(tx, r, now).Delete(&Post{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+
+		if err := tx.Unscoped().Where("post_id NOT IN (SELECT id FROM posts)").Delete(&Comment{}).Error; err != nil {
+			return err
+		}
+
+		snapshots := tx.Where("post_id NOT IN (SELECT id FROM posts)")
+		if r.SnapshotAge > 0 {
+			snapshots = snapshots.Or("taken_at < ? AND id NOT IN (SELECT MAX(id) FROM post_snapshots GROUP BY post_id)", now.Add(-r.SnapshotAge))
+		}
+		return snapshots.Delete(&PostSnapshot{}).Error
+	})
+	return deleted, err
+}
+
+// Vacuum rebuilds the database file, returning the space pruned rows
+// occupied to the file system.
+func Vacuum(database *gorm.DB) error {
+	err := writeRaw(database, func(db *gorm.DB) error {
+		return db.Exec("VACUUM").Error
+	})
+	if err != nil {
+		return err
+	}
+	return SetSetting(database, lastVacuumKey, time.Now().Format(time.RFC3339))
+}
+
+// VacuumIfDue vacuums the database if it hasn't been for every, and
+// reports whether it did. A database never vacuumed counts as just done, so
+// the first vacuum comes a full period after pruning starts.
+func VacuumIfDue(database *gorm.DB, every time.Duration, now time.Time) (bool, error) {
+	if every <= 0 {
+		return false, nil
+	}
+
+	value, err := GetSetting(database, lastVacuumKey)
+	if err != nil {
+		return false, SetSetting(database, lastVacuumKey, now.Format(time.RFC3339))
+	}
+	if last, err := time.Parse(time.RFC3339, value); err == nil && now.Sub(last) < every {
+		return false, nil
+	}
+
+	return true, Vacuum(database)
+}
@@ -12,14 +12,23 @@ func GetSetting(db *gorm.DB, key string) (string, error) {
 }
 
 func SetSetting(db *gorm.DB, key, value string) error {
-	var setting Setting
-	result := db.Where("key = ?", key).First(&setting)
+	return Write(db, func(tx *gorm.DB) error {
+		var setting Setting
+		result := tx.Where("key = ?", key).First(&setting)
 
-	if result.Error == gorm.ErrRecordNotFound {
-		setting = Setting{Key: key, Value: value}
-		return db.Create(&setting).Error
-	}
+		if result.Error == gorm.ErrRecordNotFound {
+			setting = Setting{Key: key, Value: value}
+			return tx.Create(&setting).Error
+		}
+
+		setting.Value = value
+		return tx.Save(&setting).Error
+	})
+}
 
-	setting.Value = value
-	return db.Save(&setting).Error
+// DeleteSettings removes the settings with the given keys, if present.
+func DeleteSettings(db *gorm.DB, keys ...string) error {
+	return Write(db, func(tx *gorm.DB) error {
+		return tx.Unscoped().Where("key IN ?", keys).Delete(&Setting{}).Error
+	})
 }
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Baseline is a post's score as of the start of a window, or of its first
+// snapshot within it, what its score has grown from since.
+type Baseline struct {
+	SourceType string
+	ExternalID string
+	Score      int
+	TakenAt    time.Time
+}
+
+// Baselines returns the baseline of every post with a snapshot. Snapshots
+// are only taken on change, so a post's score at since is that of its last
+// snapshot before then; posts first recorded later start from their first.
+func Baselines(database *gorm.DB, since time.Time) ([]Baseline, error) {
+	var baselines []Baseline
+	err := database.Raw(`
+		SELECT p.source_type, p.external_id, s.score, s.taken_at
+		FROM post_snapshots s
+		JOIN (
+			SELECT post_id, COALESCE(MAX(CASE WHEN taken_at <= ? THEN taken_at END), MIN(taken_at)) AS base_at
+			FROM post_snapshots
+			GROUP BY post_id
+		) f ON f.post_id = s.post_id AND f.base_at = s.taken_at
+		JOIN posts p ON p.id = s.post_id`, since).
+		Scan(&baselines).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range baselines {
+		if baselines[i].TakenAt.Before(since) {
+			baselines[i].TakenAt = since
+		}
+	}
+	return baselines, nil
+}
+
+// History returns a post's snapshots, oldest first.
+func History(database *gorm.DB, sourceType, externalID string) ([]PostSnapshot, error) {
+	var snapshots []PostSnapshot
+	err := database.
+		Joins("JOIN posts ON posts.id = post_snapshots.post_id").
+		Where("posts.source_type = ? AND posts.external_id = ?", sourceType, externalID).
+		Order("post_snapshots.taken_at").
+		Find(&snapshots).Error
+	return snapshots, err
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestSnapshotsOnlyOnChange(t *testing.T) {
+	database := openTestDB(t, filepath.Join(t.TempDir(), "data.sqlite3"))
+	if _, _, err := Migrate(database); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	source := Source{Type: "test", Identifier: "source"}
+	if err := Write(database, func(tx *gorm.DB) error { return tx.Create(&source).Error }); err != nil {
+		t.Fatalf("create source: %v", err)
+	}
+
+	fetch := func(score, comments int) {
+		t.Helper()
+		post := Post{SourceID: source.ID, SourceType: "test", ExternalID: "1", Title: "post", Score: score, NumComments: comments}
+		if _, _, err := UpsertPosts(database, source.ID, []Post{post}); err != nil {
+			t.Fatalf("UpsertPosts: %v", err)
+		}
+	}
+
+	steps := []struct {
+		score, comments int
+		want            int64
+	}{
+		{10, 1, 1},
+		{10, 1, 1},
+		{12, 1, 2},
+		{12, 3, 3},
+		{12, 3, 3},
+		{10, 1, 4},
+	}
+	for i, step := range steps {
+		fetch(step.score, step.comments)
+		if n := count(t, database, &PostSnapshot{}); n != step.want {
+			t.Errorf("after fetch %d: got %d snapshots, want %d", i+1, n, step.want)
+		}
+	}
+}
+
+func TestBaselinesAndPruneKeepLatest(t *testing.T) {
+	database := openTestDB(t, filepath.Join(t.TempDir(), "data.sqlite3"))
+	if _, _, err := Migrate(database); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	source := Source{Type: "test", Identifier: "source"}
+	if err := database.Create(&source).Error; err != nil {
+		t.Fatalf("create source: %v", err)
+	}
+
+	now := time.Now().UTC()
+	post := Post{SourceID: source.ID, SourceType: "test", ExternalID: "1", Title: "post", Score: 30}
+	if err := database.Create(&post).Error; err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+	for _, s := range []PostSnapshot{
+		{PostID: post.ID, Score: 5, TakenAt: now.Add(-10 * 24 * time.Hour)},
+		{PostID: post.ID, Score: 10, TakenAt: now.Add(-48 * time.Hour)},
+		{PostID: post.ID, Score: 30, TakenAt: now.Add(-time.Hour)},
+	} {
+		if err := database.Create(&s).Error; err != nil {
+			t.Fatalf("create snapshot: %v", err)
+		}
+	}
+
+	// The window starts between the last two snapshots, so the score at its
+	// start is that of the one before it.
+	since := now.Add(-24 * time.Hour)
+	baselines, err := Baselines(database, since)
+	if err != nil {
+		t.Fatalf("Baselines: %v", err)
+	}
+	if len(baselines) != 1 || baselines[0].Score != 10 || !baselines[0].TakenAt.Equal(since) {
+		t.Errorf("Baselines = %+v, want score 10 as of %v", baselines, since)
+	}
+
+	if _, err := Prune(database, Retention{SnapshotAge: 7 * 24 * time.Hour}, now); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if n := count(t, database, &PostSnapshot{}); n != 2 {
+		t.Errorf("got %d snapshots after pruning, want 2", n)
+	}
+
+	// Once every snapshot is past the retention, the latest still holds the
+	// post's score.
+	if _, err := Prune(database, Retention{SnapshotAge: time.Minute}, now); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	var kept []PostSnapshot
+	if err := database.Find(&kept).Error; err != nil {
+		t.Fatalf("find snapshots: %v", err)
+	}
+	if len(kept) != 1 || kept[0].Score != 30 {
+		t.Errorf("kept %+v, want only the latest snapshot", kept)
+	}
+}
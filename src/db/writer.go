@@ -0,0 +1,78 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// SQLite allows one writer at a time. Rather than have goroutines race for
+// the lock and fail with "database is locked", every write in this process
+// goes through a single queue per database. The busy timeout set in Open
+// covers contention with other processes.
+
+type writeRequest struct {
+	fn   func(tx *gorm.DB) error
+	raw  bool // run fn outside a transaction
+	done chan error
+}
+
+var (
+	writersMu sync.Mutex
+	writers   = make(map[*sql.DB]chan writeRequest)
+)
+
+// Write runs fn in a transaction on the database's writer, after any writes
+// queued before it, and returns its error. fn must not call Write itself.
+func Write(database *gorm.DB, fn func(tx *gorm.DB) error) error {
+	return write(database, writeRequest{fn: fn})
+}
+
+// writeRaw is Write for statements that can't run in a transaction, such
+// as VACUUM.
+func writeRaw(database *gorm.DB, fn func(db *gorm.DB) error) error {
+	return write(database, writeRequest{fn: fn, raw: true})
+}
+
+func write(database *gorm.DB, req writeRequest) error {
+	queue, err := writerFor(database)
+	if err != nil {
+		return err
+	}
+
+	req.done = make(chan error, 1)
+	queue <- req
+	return <-req.done
+}
+
+// writerFor returns the queue for database's connection pool, starting its
+// writer on first use. Writers live as long as the process.
+func writerFor(database *gorm.DB) (chan writeRequest, error) {
+	sqlDB, err := database.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database handle: %w", err)
+	}
+
+	writersMu.Lock()
+	defer writersMu.Unlock()
+
+	queue, ok := writers[sqlDB]
+	if !ok {
+		queue = make(chan writeRequest, 64)
+		writers[sqlDB] = queue
+		go runWriter(database.Session(&gorm.Session{NewDB: true}), queue)
+	}
+	return queue, nil
+}
+
+func runWriter(database *gorm.DB, queue chan writeRequest) {
+	for req := range queue {
+		if req.raw {
+			req.done <- req.fn(database)
+			continue
+		}
+		req.done <- database.Transaction(req.fn)
+	}
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func openTestDB(t *testing.T, path string) *gorm.DB {
+	t.Helper()
+
+	database, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return database
+}
+
+func count(t *testing.T, database *gorm.DB, model any) int64 {
+	t.Helper()
+
+	var n int64
+	if err := database.Unscoped().Model(model).Count(&n).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	return n
+}
+
+func TestWriteConcurrent(t *testing.T) {
+	database := openTestDB(t, filepath.Join(t.TempDir(), "data.sqlite3"))
+	if _, _, err := Migrate(database); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	const (
+		sources  = 4
+		workers  = 32
+		perFetch = 25
+	)
+
+	sourceIDs := make([]uint, sources)
+	for i := range sourceIDs {
+		source := Source{Type: "test", Identifier: fmt.Sprintf("source-%d", i)}
+		if err := Write(database, func(tx *gorm.DB) error { return tx.Create(&source).Error }); err != nil {
+			t.Fatalf("create source: %v", err)
+		}
+		sourceIDs[i] = source.ID
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*3)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sourceID := sourceIDs[w%sources]
+			posts := make([]Post, perFetch)
+			for i := range posts {
+				posts[i] = Post{
+					SourceID:   sourceID,
+					SourceType: "test",
+					ExternalID: fmt.Sprintf("%d-%d", w, i),
+					Title:      "post",
+					Score:      i + 1,
+					CreatedUTC: float64(time.Now().Unix()),
+				}
+			}
+
+			if created, _, err := UpsertPosts(database, sourceID, posts); err != nil {
+				errs <- fmt.Errorf("UpsertPosts: %w", err)
+			} else if created != perFetch {
+				errs <- fmt.Errorf("UpsertPosts created %d posts, want %d", created, perFetch)
+			}
+			if err := SetSetting(database, fmt.Sprintf("key-%d", w), "value"); err != nil {
+				errs <- fmt.Errorf("SetSetting: %w", err)
+			}
+			if _, err := Prune(database, Retention{}, time.Now()); err != nil {
+				errs <- fmt.Errorf("Prune: %w", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if n := count(t, database, &Post{}); n != workers*perFetch {
+		t.Errorf("got %d posts, want %d", n, workers*perFetch)
+	}
+	if n := count(t, database, &PostSnapshot{}); n != workers*perFetch {
+		t.Errorf("got %d snapshots, want %d", n, workers*perFetch)
+	}
+	if n := count(t, database, &Setting{}); n != workers {
+		t.Errorf("got %d settings, want %d", n, workers)
+	}
+}
+
+func TestWriteWaitsForOtherProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.sqlite3")
+	database := openTestDB(t, path)
+	if _, _, err := Migrate(database); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	// A second pool on the same file has its own writer, as another process
+	// would.
+	other := openTestDB(t, path)
+
+	var timeout int64
+	if err := other.Raw("PRAGMA busy_timeout").Scan(&timeout).Error; err != nil {
+		t.Fatalf("busy_timeout: %v", err)
+	}
+	if timeout != busyTimeout.Milliseconds() {
+		t.Errorf("busy_timeout is %dms, want %dms", timeout, busyTimeout.Milliseconds())
+	}
+
+	const hold = 300 * time.Millisecond
+	locked := make(chan struct{})
+	held := make(chan error, 1)
+	go func() {
+		held <- Write(database, func(tx *gorm.DB) error {
+			if err := tx.Create(&Setting{Key: "holder", Value: "1"}).Error; err != nil {
+				return err
+			}
+			close(locked)
+			time.Sleep(hold)
+			return nil
+		})
+	}()
+	<-locked
+
+	start := time.Now()
+	if err := SetSetting(other, "waiter", "1"); err != nil {
+		t.Fatalf("SetSetting while another connection writes: %v", err)
+	}
+	if waited := time.Since(start); waited < hold/2 {
+		t.Errorf("write took %v, want it to wait for the other writer", waited)
+	}
+	if err := <-held; err != nil {
+		t.Fatalf("holding write: %v", err)
+	}
+
+	if n := count(t, database, &Setting{}); n != 2 {
+		t.Errorf("got %d settings, want 2", n)
+	}
+}
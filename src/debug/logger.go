@@ -4,12 +4,20 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
+
+	"github.com/snoofox/snoo/src/paths"
 )
 
-var logFile *os.File
+var (
+	logFile *os.File
+	openLog sync.Once
+)
 
-func init() {
+// open opens the log on first use rather than at init, so that startup can
+// move an old log into place before anything is written.
+func open() {
 	logPath, err := getLogPath()
 	if err != nil {
 		return
@@ -17,25 +25,25 @@ func init() {
 
 	logFile, err = os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
-		return
+		logFile = nil
 	}
 }
 
 func getLogPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	stateDir, err := paths.StateDir()
 	if err != nil {
 		return "", err
 	}
 
-	snooDir := filepath.Join(homeDir, ".snoo")
-	if err := os.MkdirAll(snooDir, 0755); err != nil {
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
 		return "", err
 	}
 
-	return filepath.Join(snooDir, "debug.log"), nil
+	return filepath.Join(stateDir, "debug.log"), nil
 }
 
 func Log(format string, args ...interface{}) {
+	openLog.Do(open)
 	if logFile == nil {
 		return
 	}
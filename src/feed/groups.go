@@ -0,0 +1,79 @@
+package feed
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/snoofox/snoo/src/db"
+	"gorm.io/gorm"
+)
+
+// AllGroup names the feed tab showing every source. No group may take it.
+const AllGroup = "All"
+
+// Groups returns the names of the source groups, oldest first.
+func (m *Manager) Groups() ([]string, error) {
+	var names []string
+	if err := m.db.Model(&db.Group{}).Order("id").Pluck("name", &names).Error; err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	return names, nil
+}
+
+func (m *Manager) AddGroup(name string) error {
+	if err := checkGroupName(name); err != nil {
+		return err
+	}
+
+	return db.Write(m.db, func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&db.Group{}).Where("name = ?", name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("group %s already exists", name)
+		}
+		return tx.Create(&db.Group{Name: name}).Error
+	})
+}
+
+// RemoveGroup deletes a group. Its sources are kept, outside any group.
+func (m *Manager) RemoveGroup(name string) error {
+	return db.Write(m.db, func(tx *gorm.DB) error {
+		var group db.Group
+		if err := tx.Where("name = ?", name).First(&group).Error; err != nil {
+			return fmt.Errorf("group %s does not exist", name)
+		}
+
+		if err := tx.Model(&db.Source{}).Where("group_id = ?", group.ID).Update("group_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&group).Error
+	})
+}
+
+// SetSourceGroup moves a source into a group, or out of any group if name
+// is empty.
+func (m *Manager) SetSourceGroup(id uint, name string) error {
+	if name == "" {
+		return m.UpdateSource(id, "group_id", nil)
+	}
+
+	var group db.Group
+	if err := m.db.Where("name = ?", name).First(&group).Error; err != nil {
+		return fmt.Errorf("group %s does not exist (create it with 'snoo group add %s')", name, name)
+	}
+	return m.UpdateSource(id, "group_id", group.ID)
+}
+
+func checkGroupName(name string) error {
+	switch {
+	case strings.TrimSpace(name) != name || name == "":
+		return fmt.Errorf("group name can't be empty or start or end with spaces")
+	case len(name) > 64:
+		return fmt.Errorf("group name is too long")
+	case strings.EqualFold(name, AllGroup):
+		return fmt.Errorf("%q is reserved for the tab showing every source", AllGroup)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package feed
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,17 +12,86 @@ import (
 	"gorm.io/gorm"
 )
 
+const defaultRefreshInterval = time.Hour
+
+const (
+	// trendWindow is how far back velocity looks for a post's score.
+	trendWindow = 24 * time.Hour
+	// minTrendSpan is the least score history velocity is measured over;
+	// shorter spans are mostly noise.
+	minTrendSpan = 30 * time.Minute
+)
+
+var (
+	refreshMu        sync.RWMutex
+	refreshIntervals = make(map[string]time.Duration)
+
+	retentionMu    sync.RWMutex
+	retention      db.Retention
+	vacuumInterval time.Duration
+)
+
+// SetRefreshInterval sets how long posts from a provider type are served
+// from the cache before fetching again. An empty type sets the default.
+func SetRefreshInterval(providerType string, d time.Duration) {
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+	refreshIntervals[providerType] = d
+}
+
+func refreshInterval(providerType string) time.Duration {
+	refreshMu.RLock()
+	defer refreshMu.RUnlock()
+	if d, ok := refreshIntervals[providerType]; ok {
+		return d
+	}
+	if d, ok := refreshIntervals[""]; ok {
+		return d
+	}
+	return defaultRefreshInterval
+}
+
+// SetRetention sets which posts are pruned after a refresh and how often
+// the database is vacuumed afterwards. The zero values disable both.
+func SetRetention(r db.Retention, vacuum time.Duration) {
+	retentionMu.Lock()
+	defer retentionMu.Unlock()
+	retention = r
+	vacuumInterval = vacuum
+}
+
+func retentionPolicy() (db.Retention, time.Duration) {
+	retentionMu.RLock()
+	defer retentionMu.RUnlock()
+	return retention, vacuumInterval
+}
+
 type Manager struct {
 	db *gorm.DB
+
+	// since and until bound the creation times of the posts FetchAll
+	// returns; zero leaves that end open.
+	since, until time.Time
 }
 
 func NewManager(database *gorm.DB) *Manager {
 	return &Manager{db: database}
 }
 
+// SetWindow limits the posts FetchAll returns to those created at or after
+// since and before until. A zero time leaves that end open.
+func (m *Manager) SetWindow(since, until time.Time) {
+	m.since, m.until = since, until
+}
+
+func (m *Manager) inWindow(p Post) bool {
+	return (m.since.IsZero() || !p.CreatedAt.Before(m.since)) &&
+		(m.until.IsZero() || p.CreatedAt.Before(m.until))
+}
+
 func (m *Manager) FetchAll(ctx context.Context) ([]Post, error) {
 	var sources []db.Source
-	if err := m.db.Find(&sources).Error; err != nil {
+	if err := m.db.Preload("Group").Find(&sources).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch sources: %w", err)
 	}
 
@@ -32,6 +102,7 @@ func (m *Manager) FetchAll(ctx context.Context) ([]Post, error) {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	allPosts := []Post{}
+	fetched := false
 
 	for _, src := range sources {
 		wg.Add(1)
@@ -45,7 +116,12 @@ func (m *Manager) FetchAll(ctx context.Context) ([]Post, error) {
 			}
 
 			feedSource := dbSourceToFeedSource(source)
-			posts, err := m.fetchOrGetCached(ctx, provider, feedSource)
+			posts, fresh, err := m.fetchOrGetCached(ctx, provider, feedSource)
+			if err != nil && ctx.Err() != nil {
+				// Interrupted or past the deadline: show what we have.
+				debug.Log("Fetch of %s cancelled, using cached posts: %v", source.Name, err)
+				posts, err = m.cachedPosts(source.ID), nil
+			}
 			if err != nil {
 				fmt.Printf("Error fetching from %s: %v\n", source.Name, err)
 				return
@@ -53,66 +129,184 @@ func (m *Manager) FetchAll(ctx context.Context) ([]Post, error) {
 
 			mu.Lock()
 			allPosts = append(allPosts, posts...)
+			fetched = fetched || fresh
 			mu.Unlock()
 		}(src)
 	}
 
 	wg.Wait()
+
+	if fetched {
+		m.prune()
+	}
+	feedSources := make([]Source, len(sources))
+	for i, s := range sources {
+		feedSources[i] = dbSourceToFeedSource(s)
+	}
+	m.fillVelocity(allPosts)
+	Rank(allPosts, feedSources, time.Now())
 	return allPosts, nil
 }
 
-func (m *Manager) fetchOrGetCached(ctx context.Context, provider Provider, source Source) ([]Post, error) {
+// fillVelocity sets how many points each post gained per hour over the
+// recorded score history of the last trendWindow. Posts without enough
+// history get their average since being posted.
+func (m *Manager) fillVelocity(posts []Post) {
 	now := time.Now()
-	needsFetch := source.LastFetchAt == nil || now.Sub(*source.LastFetchAt) > time.Hour
 
-	if !needsFetch {
-		var cachedPosts []db.Post
-		m.db.Where("source_id = ?", source.ID).Order("created_utc DESC").Find(&cachedPosts)
+	baselines, err := db.Baselines(m.db, now.Add(-trendWindow))
+	if err != nil {
+		debug.Log("Error loading score history: %v", err)
+	}
 
-		if len(cachedPosts) > 0 {
-			posts := make([]Post, len(cachedPosts))
-			for i, p := range cachedPosts {
-				posts[i] = dbPostToFeedPost(p)
+	type postKey struct{ typ, id string }
+	byPost := make(map[postKey]db.Baseline, len(baselines))
+	for _, b := range baselines {
+		byPost[postKey{b.SourceType, b.ExternalID}] = b
+	}
+
+	for i := range posts {
+		p := &posts[i]
+		if b, ok := byPost[postKey{p.SourceType, p.ID}]; ok {
+			if span := now.Sub(b.TakenAt); span >= minTrendSpan {
+				p.Velocity = float64(p.Score-b.Score) / span.Hours()
+				continue
 			}
-			return posts, nil
 		}
+
+		age := max(now.Sub(p.CreatedAt), time.Hour)
+		p.Velocity = float64(p.Score) / age.Hours()
+	}
+}
+
+// prune applies the retention policy after a refresh stored new posts,
+// vacuuming when one is due.
+func (m *Manager) prune() {
+	r, vacuum := retentionPolicy()
+	if r.MaxAge > 0 || r.PerSource > 0 || r.SnapshotAge > 0 {
+		deleted, err := db.Prune(m.db, r, time.Now())
+		if err != nil {
+			debug.Log("Error pruning posts: %v", err)
+			return
+		}
+		debug.Log("Pruned %d posts", deleted)
+	}
+
+	vacuumed, err := db.VacuumIfDue(m.db, vacuum, time.Now())
+	if err != nil {
+		debug.Log("Error vacuuming database: %v", err)
+	} else if vacuumed {
+		debug.Log("Vacuumed database")
+	}
+}
+
+// fetchOrGetCached returns the source's posts, fetching them if the cache
+// is stale, and reports whether it fetched.
+func (m *Manager) fetchOrGetCached(ctx context.Context, provider Provider, source Source) ([]Post, bool, error) {
+	now := time.Now()
+	needsFetch := source.LastFetchAt == nil || now.Sub(*source.LastFetchAt) > refreshInterval(source.Type)
+
+	if !needsFetch && m.hasCachedPosts(source.ID) {
+		return m.cachedPosts(source.ID), false, nil
 	}
 
 	posts, err := provider.FetchPosts(ctx, source)
 	if err != nil {
 		debug.Log("Error fetching posts from %s: %v", source.Name, err)
-		return nil, err
+		return nil, false, err
 	}
 
 	debug.Log("Fetched %d posts from %s (%s)", len(posts), source.Name, source.Type)
 
-	m.db.Model(&db.Source{}).Where("id = ?", source.ID).Update("last_fetch_at", now)
+	for i := range posts {
+		posts[i].SourceID = source.ID
+	}
+
+	err = db.Write(m.db, func(tx *gorm.DB) error {
+		return tx.Model(&db.Source{}).Where("id = ?", source.ID).Update("last_fetch_at", now).Error
+	})
+	if err != nil {
+		debug.Log("Error updating fetch time for %s: %v", source.Name, err)
+	}
 
-	savedCount := 0
+	dbPosts := make([]db.Post, 0, len(posts))
 	for i, post := range posts {
 		if post.ID == "" {
 			debug.Log("Post %d has empty ID, title: %s", i, post.Title)
 			continue
 		}
+		dbPosts = append(dbPosts, feedPostToDBPost(post, source.ID))
+	}
 
-		dbPost := feedPostToDBPost(post, source.ID)
-
-		var existing db.Post
-		result := m.db.Where("external_id = ? AND source_id = ?", post.ID, source.ID).First(&existing)
-		if result.Error == nil {
-			m.db.Model(&existing).Updates(dbPost)
-		} else {
-			if err := m.db.Create(&dbPost).Error; err != nil {
-				debug.Log("Error creating post: %v", err)
-			} else {
-				savedCount++
-			}
+	created, updated, err := db.UpsertPosts(m.db, source.ID, dbPosts)
+	if err != nil {
+		debug.Log("Error saving posts from %s: %v", source.Name, err)
+	} else {
+		debug.Log("Saved %d new and %d updated posts from %s", created, updated, source.Name)
+	}
+
+	m.loadReaderState(source.ID, posts)
+
+	inWindow := posts[:0]
+	for _, p := range posts {
+		if m.inWindow(p) {
+			inWindow = append(inWindow, p)
 		}
 	}
+	return inWindow, true, nil
+}
+
+// loadReaderState copies what the reader stored about fetched posts, such
+// as whether they were read or saved, from the database.
+func (m *Manager) loadReaderState(sourceID uint, posts []Post) {
+	ids := make([]string, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+
+	var stored []db.Post
+	m.db.Select("external_id", "read_at", "saved_at", "note").
+		Where("source_id = ? AND external_id IN ?", sourceID, ids).
+		Find(&stored)
 
-	debug.Log("Saved %d new posts from %s", savedCount, source.Name)
+	byID := make(map[string]db.Post, len(stored))
+	for _, p := range stored {
+		byID[p.ExternalID] = p
+	}
+	for i := range posts {
+		if p, ok := byID[posts[i].ID]; ok {
+			posts[i].ReadAt = p.ReadAt
+			posts[i].SavedAt = p.SavedAt
+			posts[i].Note = p.Note
+		}
+	}
+}
 
-	return posts, nil
+func (m *Manager) hasCachedPosts(sourceID uint) bool {
+	var count int64
+	m.db.Model(&db.Post{}).Where("source_id = ?", sourceID).Limit(1).Count(&count)
+	return count > 0
+}
+
+// cachedPosts returns the stored posts of a source within the window,
+// newest first.
+func (m *Manager) cachedPosts(sourceID uint) []Post {
+	query := m.db.Where("source_id = ?", sourceID)
+	if !m.since.IsZero() {
+		query = query.Where("created_utc >= ?", float64(m.since.Unix()))
+	}
+	if !m.until.IsZero() {
+		query = query.Where("created_utc < ?", float64(m.until.Unix()))
+	}
+
+	var cachedPosts []db.Post
+	query.Order("created_utc DESC").Find(&cachedPosts)
+
+	posts := make([]Post, len(cachedPosts))
+	for i, p := range cachedPosts {
+		posts[i] = dbPostToFeedPost(p)
+	}
+	return posts
 }
 
 func (m *Manager) Subscribe(ctx context.Context, providerType, identifier string) error {
@@ -121,8 +315,7 @@ func (m *Manager) Subscribe(ctx context.Context, providerType, identifier string
 		return err
 	}
 
-	var existing db.Source
-	if err := m.db.Where("type = ? AND identifier = ?", providerType, identifier).First(&existing).Error; err == nil {
+	if m.subscribed(providerType, identifier) {
 		return fmt.Errorf("already subscribed to this source")
 	}
 
@@ -130,6 +323,10 @@ func (m *Manager) Subscribe(ctx context.Context, providerType, identifier string
 	if err != nil {
 		return fmt.Errorf("failed to validate source: %w", err)
 	}
+	// Providers normalize identifiers, so t/Rust and t/rust are one source.
+	if m.subscribed(providerType, metadata.Name) {
+		return fmt.Errorf("already subscribed to this source")
+	}
 
 	source := &db.Source{
 		Type:        providerType,
@@ -138,29 +335,52 @@ func (m *Manager) Subscribe(ctx context.Context, providerType, identifier string
 		DisplayName: metadata.DisplayName,
 		Description: metadata.Description,
 		IconURL:     metadata.IconURL,
+		Metadata:    metadata.Metadata,
 	}
 
-	if err := m.db.Create(source).Error; err != nil {
+	if err := db.Write(m.db, func(tx *gorm.DB) error { return tx.Create(source).Error }); err != nil {
 		return fmt.Errorf("failed to create source: %w", err)
 	}
 
 	return nil
 }
 
+func (m *Manager) subscribed(providerType, identifier string) bool {
+	var count int64
+	m.db.Model(&db.Source{}).Where("type = ? AND identifier = ?", providerType, identifier).Count(&count)
+	return count > 0
+}
+
 func (m *Manager) Unsubscribe(id uint) error {
-	result := m.db.Unscoped().Delete(&db.Source{}, id)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("source not found")
-	}
-	return nil
+	return db.Write(m.db, func(tx *gorm.DB) error {
+		result := tx.Unscoped().Delete(&db.Source{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("source not found")
+		}
+		return nil
+	})
+}
+
+// UpdateSource changes one setting of a source, such as its weight.
+func (m *Manager) UpdateSource(id uint, column string, value any) error {
+	return db.Write(m.db, func(tx *gorm.DB) error {
+		result := tx.Model(&db.Source{}).Where("id = ?", id).Update(column, value)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("source not found")
+		}
+		return nil
+	})
 }
 
 func (m *Manager) ListSources() ([]Source, error) {
 	var dbSources []db.Source
-	if err := m.db.Find(&dbSources).Error; err != nil {
+	if err := m.db.Preload("Group").Find(&dbSources).Error; err != nil {
 		return nil, err
 	}
 
@@ -182,18 +402,59 @@ func (m *Manager) FetchComments(ctx context.Context, post Post) ([]Comment, erro
 
 func (m *Manager) MarkAsRead(ctx context.Context, sourceType, externalID string) error {
 	now := time.Now()
-	result := m.db.Model(&db.Post{}).
-		Where("source_type = ? AND external_id = ?", sourceType, externalID).
-		Update("read_at", now)
+	err := db.Write(m.db, func(tx *gorm.DB) error {
+		return tx.Model(&db.Post{}).
+			Where("source_type = ? AND external_id = ?", sourceType, externalID).
+			Update("read_at", now).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark post as read: %w", err)
+	}
+
+	return nil
+}
+
+// History returns a post's recorded scores and comment counts, oldest
+// first.
+func (m *Manager) History(sourceType, externalID string) ([]Snapshot, error) {
+	stored, err := db.History(m.db, sourceType, externalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load post history: %w", err)
+	}
+
+	history := make([]Snapshot, len(stored))
+	for i, s := range stored {
+		history[i] = Snapshot{Score: s.Score, NumComments: s.NumComments, TakenAt: s.TakenAt}
+	}
+	return history, nil
+}
+
+// SetSaved saves a post, keeping it through pruning, or unsaves it.
+func (m *Manager) SetSaved(ctx context.Context, sourceType, externalID string, saved bool) error {
+	var savedAt *time.Time
+	if saved {
+		now := time.Now()
+		savedAt = &now
+	}
 
-	if result.Error != nil {
-		return fmt.Errorf("failed to mark post as read: %w", result.Error)
+	err := db.Write(m.db, func(tx *gorm.DB) error {
+		return tx.Model(&db.Post{}).
+			Where("source_type = ? AND external_id = ?", sourceType, externalID).
+			Update("saved_at", savedAt).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save post: %w", err)
 	}
 
 	return nil
 }
 
 func dbSourceToFeedSource(s db.Source) Source {
+	var group string
+	if s.Group != nil {
+		group = s.Group.Name
+	}
+
 	return Source{
 		ID:          s.ID,
 		Type:        s.Type,
@@ -202,7 +463,12 @@ func dbSourceToFeedSource(s db.Source) Source {
 		DisplayName: s.DisplayName,
 		Description: s.Description,
 		IconURL:     s.IconURL,
+		Metadata:    s.Metadata,
 		LastFetchAt: s.LastFetchAt,
+		Weight:      s.Weight,
+		Pinned:      s.Pinned,
+		MaxPosts:    s.MaxPosts,
+		Group:       group,
 	}
 }
 
@@ -212,8 +478,19 @@ func dbPostToFeedPost(p db.Post) Post {
 		readAt = p.ReadAt
 	}
 
+	var enclosure *Enclosure
+	if p.EnclosureURL != "" {
+		enclosure = &Enclosure{
+			URL:      p.EnclosureURL,
+			Type:     p.EnclosureType,
+			Length:   p.EnclosureLength,
+			Duration: time.Duration(p.EnclosureDuration) * time.Second,
+		}
+	}
+
 	return Post{
 		ID:          p.ExternalID,
+		SourceID:    p.SourceID,
 		Title:       p.Title,
 		Author:      p.Author,
 		SourceName:  p.SourceName,
@@ -227,11 +504,16 @@ func dbPostToFeedPost(p db.Post) Post {
 		Thumbnail:   p.Thumbnail,
 		NSFW:        p.NSFW,
 		ReadAt:      readAt,
+		SavedAt:     p.SavedAt,
+		Note:        p.Note,
+		Enclosure:   enclosure,
+		CommentsURL: p.CommentsURL,
+		Tags:        splitTags(p.Tags),
 	}
 }
 
 func feedPostToDBPost(p Post, sourceID uint) db.Post {
-	return db.Post{
+	dbPost := db.Post{
 		SourceID:    sourceID,
 		SourceType:  p.SourceType,
 		ExternalID:  p.ID,
@@ -246,5 +528,23 @@ func feedPostToDBPost(p Post, sourceID uint) db.Post {
 		Content:     p.Content,
 		Thumbnail:   p.Thumbnail,
 		NSFW:        p.NSFW,
+		CommentsURL: p.CommentsURL,
+		Tags:        strings.Join(p.Tags, ","),
+	}
+
+	if p.Enclosure != nil {
+		dbPost.EnclosureURL = p.Enclosure.URL
+		dbPost.EnclosureType = p.Enclosure.Type
+		dbPost.EnclosureLength = p.Enclosure.Length
+		dbPost.EnclosureDuration = int(p.Enclosure.Duration.Seconds())
+	}
+
+	return dbPost
+}
+
+func splitTags(tags string) []string {
+	if tags == "" {
+		return nil
 	}
+	return strings.Split(tags, ",")
 }
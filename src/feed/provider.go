@@ -12,6 +12,24 @@ type Provider interface {
 	ValidateSource(ctx context.Context, identifier string) (*SourceMetadata, error)
 }
 
+// CommentsReporter is implemented by providers that only serve comments for
+// some setups (e.g. plugins), so callers can skip FetchComments.
+type CommentsReporter interface {
+	HasComments() bool
+}
+
+// URLMatcher is implemented by providers that can recognize their own URLs
+// and shorthands, so `snoo sub <url>` can route to them.
+type URLMatcher interface {
+	MatchURL(input string) (identifier string, ok bool)
+}
+
+// CatchAllMatcher marks a URLMatcher that accepts generic URLs (e.g. any
+// feed). Resolve only consults it after every specific matcher declined.
+type CatchAllMatcher interface {
+	MatchesAnyURL() bool
+}
+
 type Source struct {
 	ID          uint
 	Type        string
@@ -22,6 +40,10 @@ type Source struct {
 	IconURL     string
 	Metadata    map[string]interface{}
 	LastFetchAt *time.Time
+	Weight      float64 // scales the source in the hot sort
+	Pinned      bool    // its posts come first
+	MaxPosts    int     // most posts shown at once, 0 for no limit
+	Group       string  // the group the source is in, if any
 }
 
 type SourceMetadata struct {
@@ -34,6 +56,7 @@ type SourceMetadata struct {
 
 type Post struct {
 	ID          string
+	SourceID    uint // set by Manager
 	Title       string
 	Author      string
 	SourceName  string
@@ -47,6 +70,28 @@ type Post struct {
 	Thumbnail   string
 	NSFW        bool
 	ReadAt      *time.Time
+	SavedAt     *time.Time // kept through pruning while set
+	Note        string     // the reader's annotation
+	Velocity    float64    // score gained per hour lately, set by FetchAll
+	Hot         float64    // rank balanced across sources, set by Rank
+	Enclosure   *Enclosure
+	CommentsURL string // per-post comment feed, if the provider needs one
+	Tags        []string
+}
+
+// Enclosure is a media attachment, such as a podcast episode.
+type Enclosure struct {
+	URL      string
+	Type     string // MIME type
+	Length   int64  // bytes, 0 if unknown
+	Duration time.Duration
+}
+
+// Snapshot is a post's score and comment count as of one fetch.
+type Snapshot struct {
+	Score       int
+	NumComments int
+	TakenAt     time.Time
 }
 
 type Comment struct {
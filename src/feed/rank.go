@@ -0,0 +1,86 @@
+package feed
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultGravity = 1.8
+
+var (
+	rankingMu   sync.RWMutex
+	gravity     = defaultGravity
+	typeWeights = make(map[string]float64)
+)
+
+// SetRanking sets how fast hot posts sink with age and how much each
+// provider type's posts weigh. Types without a weight weigh 1; a gravity of
+// zero keeps the default.
+func SetRanking(g float64, weights map[string]float64) {
+	rankingMu.Lock()
+	defer rankingMu.Unlock()
+
+	gravity = defaultGravity
+	if g > 0 {
+		gravity = g
+	}
+	typeWeights = make(map[string]float64, len(weights))
+	for typ, w := range weights {
+		typeWeights[typ] = w
+	}
+}
+
+func typeWeight(providerType string) float64 {
+	rankingMu.RLock()
+	defer rankingMu.RUnlock()
+	if w, ok := typeWeights[providerType]; ok {
+		return w
+	}
+	return 1
+}
+
+// Rank sets each post's Hot score: where its score falls among the posts of
+// its source, weighted by provider type and by source, and decayed with age
+// as in Hacker News's ranking. Using percentiles rather than raw scores
+// keeps sources with large audiences from burying small ones; in a source
+// without scores, such as most RSS feeds, every post is average and age
+// alone decides.
+func Rank(posts []Post, sources []Source, now time.Time) {
+	rankingMu.RLock()
+	g := gravity
+	rankingMu.RUnlock()
+
+	weights := make(map[uint]float64, len(sources))
+	for _, s := range sources {
+		weights[s.ID] = s.Weight
+	}
+
+	scores := make(map[uint][]int)
+	for _, p := range posts {
+		scores[p.SourceID] = append(scores[p.SourceID], p.Score)
+	}
+	for _, s := range scores {
+		sort.Ints(s)
+	}
+
+	for i := range posts {
+		p := &posts[i]
+		weight := typeWeight(p.SourceType)
+		if w, ok := weights[p.SourceID]; ok {
+			weight *= w
+		}
+
+		age := max(now.Sub(p.CreatedAt), 0).Hours()
+		p.Hot = weight * percentile(scores[p.SourceID], p.Score) / math.Pow(age+2, g)
+	}
+}
+
+// percentile returns the share of sorted that falls below score, counting
+// ties as half below, so it lies strictly between 0 and 1.
+func percentile(sorted []int, score int) float64 {
+	below := sort.SearchInts(sorted, score)
+	equal := sort.SearchInts(sorted, score+1) - below
+	return (float64(below) + float64(equal)/2) / float64(len(sorted))
+}
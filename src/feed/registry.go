@@ -2,12 +2,18 @@ package feed
 
 import (
 	"fmt"
+	"net/url"
+	"sort"
+	"strings"
 	"sync"
 )
 
 var (
 	registry = make(map[string]Provider)
 	mu       sync.RWMutex
+
+	loaders  []func()
+	loadOnce sync.Once
 )
 
 func Register(provider Provider) {
@@ -16,7 +22,41 @@ func Register(provider Provider) {
 	registry[provider.Type()] = provider
 }
 
+// RegisterNew registers provider unless its type is already taken, and
+// reports whether it did.
+func RegisterNew(provider Provider) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[provider.Type()]; ok {
+		return false
+	}
+	registry[provider.Type()] = provider
+	return true
+}
+
+// RegisterLoader adds a function that registers more providers. Loaders run
+// once, the first time a provider is looked up, so commands that never
+// fetch or subscribe don't pay for them. Loaders must register with
+// Register or RegisterNew, not look providers up.
+func RegisterLoader(fn func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	loaders = append(loaders, fn)
+}
+
+func load() {
+	loadOnce.Do(func() {
+		mu.RLock()
+		fns := loaders
+		mu.RUnlock()
+		for _, fn := range fns {
+			fn()
+		}
+	})
+}
+
 func Get(providerType string) (Provider, error) {
+	load()
 	mu.RLock()
 	defer mu.RUnlock()
 
@@ -28,6 +68,7 @@ func Get(providerType string) (Provider, error) {
 }
 
 func List() []string {
+	load()
 	mu.RLock()
 	defer mu.RUnlock()
 
@@ -37,3 +78,63 @@ func List() []string {
 	}
 	return types
 }
+
+// Resolve finds the provider that claims input (a URL or a shorthand such
+// as r/golang) and returns its type together with the identifier to
+// subscribe with. Specific matchers are consulted before catch-all ones.
+func Resolve(input string) (providerType, identifier string, err error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", "", fmt.Errorf("nothing to subscribe to")
+	}
+
+	load()
+	mu.RLock()
+	defer mu.RUnlock()
+
+	types := make([]string, 0, len(registry))
+	for t := range registry {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	for _, catchAll := range []bool{false, true} {
+		for _, t := range types {
+			matcher, ok := registry[t].(URLMatcher)
+			if !ok || isCatchAll(matcher) != catchAll {
+				continue
+			}
+			if identifier, ok := matcher.MatchURL(input); ok {
+				return t, identifier, nil
+			}
+		}
+	}
+
+	return "", "", fmt.Errorf("no provider recognizes %q", input)
+}
+
+func isCatchAll(m URLMatcher) bool {
+	c, ok := m.(CatchAllMatcher)
+	return ok && c.MatchesAnyURL()
+}
+
+// ParseURL parses input as an http(s) URL, assuming https when the scheme
+// is missing (e.g. "lobste.rs/t/go"). The host is lower-cased and a leading
+// "www." is dropped so matchers can compare hosts directly.
+func ParseURL(input string) (*url.URL, bool) {
+	if !strings.Contains(input, "://") {
+		if !strings.Contains(strings.SplitN(input, "/", 2)[0], ".") {
+			return nil, false
+		}
+		input = "https://" + input
+	}
+
+	u, err := url.Parse(input)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, false
+	}
+
+	u.Host = strings.ToLower(u.Host)
+	u.Host = strings.TrimPrefix(u.Host, "www.")
+	return u, true
+}
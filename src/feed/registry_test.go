@@ -0,0 +1,74 @@
+package feed_test
+
+import (
+	"testing"
+
+	"github.com/snoofox/snoo/src/feed"
+	"github.com/snoofox/snoo/src/providers/hackernews"
+	"github.com/snoofox/snoo/src/providers/lobsters"
+	"github.com/snoofox/snoo/src/providers/reddit"
+	"github.com/snoofox/snoo/src/providers/rss"
+)
+
+func TestResolve(t *testing.T) {
+	feed.Register(reddit.New())
+	feed.Register(rss.New())
+	feed.Register(lobsters.New())
+	feed.Register(hackernews.New())
+
+	tests := []struct {
+		input      string
+		typ        string
+		identifier string
+		ok         bool
+	}{
+		{"r/golang", "reddit", "golang", true},
+		{"https://www.reddit.com/r/golang/top/?t=week", "reddit", "golang:top/week", true},
+		{"hn/best", "hackernews", "best", true},
+		{"https://news.ycombinator.com/newest", "hackernews", "new", true},
+		{"lobste.rs/t/go", "lobsters", "t/go", true},
+		// Lobsters pages it can't follow fall through to the feed.
+		{"https://lobste.rs/rss", "rss", "https://lobste.rs/rss", true},
+		{"  example.com/feed.xml ", "rss", "https://example.com/feed.xml", true},
+		{"golang", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tt := range tests {
+		typ, identifier, err := feed.Resolve(tt.input)
+		if (err == nil) != tt.ok {
+			t.Errorf("Resolve(%q) error = %v, want ok %v", tt.input, err, tt.ok)
+			continue
+		}
+		if typ != tt.typ || identifier != tt.identifier {
+			t.Errorf("Resolve(%q) = %q, %q; want %q, %q", tt.input, typ, identifier, tt.typ, tt.identifier)
+		}
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"https://www.Example.com/a?b=c", "https://example.com/a?b=c", true},
+		{"example.com/a", "https://example.com/a", true},
+		{"http://example.com", "http://example.com", true},
+		{"r/golang", "", false},
+		{"localhost/feed", "", false},
+		{"ftp://example.com", "", false},
+		{"https://", "", false},
+	}
+
+	for _, tt := range tests {
+		u, ok := feed.ParseURL(tt.input)
+		if ok != tt.ok {
+			t.Errorf("ParseURL(%q) ok = %v, want %v", tt.input, ok, tt.ok)
+			continue
+		}
+		if ok && u.String() != tt.want {
+			t.Errorf("ParseURL(%q) = %q, want %q", tt.input, u.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Package httpclient is the HTTP layer shared by all providers. Requests go
+// through a transport that applies per-host rate limits, retries 429 and 5xx
+// responses with exponential backoff, sets the User-Agent and logs request
+// metrics to the debug log.
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultUserAgent = "snoo:v1.0.0"
+
+// attemptTimeout bounds each attempt at connecting and getting a response's
+// headers. Requests as a whole, with their retries and rate limit waits,
+// are bounded by their context instead.
+const attemptTimeout = 30 * time.Second
+
+var (
+	baseTransport = &http.Transport{
+		Proxy:                 proxyForRequest,
+		DialContext:           (&net.Dialer{Timeout: attemptTimeout}).DialContext,
+		TLSHandshakeTimeout:   attemptTimeout,
+		ResponseHeaderTimeout: attemptTimeout,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   100,
+		IdleConnTimeout:       90 * time.Second,
+	}
+
+	shared = &transport{
+		base:       baseTransport,
+		limiters:   newLimiters(),
+		maxRetries: 3,
+	}
+
+	// Default is the client for API requests. It has no overall timeout,
+	// since retries may wait on Retry-After for up to maxRetryAfter; callers
+	// bound requests with a context, such as the one --timeout sets.
+	Default = &http.Client{
+		Transport: shared,
+	}
+
+	// Streaming is for long transfers such as media downloads. It shares
+	// rate limits with Default and, like it, is bounded by its callers'
+	// contexts.
+	Streaming = &http.Client{
+		Transport: shared,
+	}
+
+	uaMu      sync.RWMutex
+	userAgent = defaultUserAgent
+)
+
+// SetUserAgent changes the User-Agent sent with requests that don't set
+// their own.
+func SetUserAgent(ua string) {
+	uaMu.Lock()
+	defer uaMu.Unlock()
+	if ua == "" {
+		ua = defaultUserAgent
+	}
+	userAgent = ua
+}
+
+// UserAgent returns the User-Agent sent with requests that don't set their
+// own.
+func UserAgent() string {
+	uaMu.RLock()
+	defer uaMu.RUnlock()
+	return userAgent
+}
+
+// SetHostLimit overrides the rate limit for host and its subdomains:
+// perSecond requests on average with bursts of up to burst requests.
+func SetHostLimit(host string, perSecond float64, burst int) {
+	shared.limiters.setLimit(host, limit{rate: perSecond, burst: float64(burst)})
+}
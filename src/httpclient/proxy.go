@@ -0,0 +1,163 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Direct is the proxy setting that disables proxying, including any proxy
+// set in the environment.
+const Direct = "direct"
+
+type providerKey struct{}
+
+var (
+	proxyMu        sync.RWMutex
+	defaultProxy   *proxySetting
+	providerProxys = make(map[string]*proxySetting)
+
+	providerClients sync.Map
+)
+
+// proxySetting is a parsed proxy value. A nil URL means direct.
+type proxySetting struct {
+	url *url.URL
+}
+
+// ParseProxy checks a proxy setting: an http, https, socks5 or socks5h URL,
+// or Direct.
+func ParseProxy(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == Direct {
+		return nil, nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", raw, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy %q: scheme must be http, https, socks5 or socks5h", raw)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: missing host", raw)
+	}
+	return u, nil
+}
+
+// SetProxy sets the proxy used for all requests. An empty value falls back
+// to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func SetProxy(raw string) error {
+	s, err := parseSetting(raw)
+	if err != nil {
+		return err
+	}
+
+	proxyMu.Lock()
+	defer proxyMu.Unlock()
+	defaultProxy = s
+	return nil
+}
+
+// SetProviderProxy overrides the proxy for requests made by one provider.
+// An empty value removes the override.
+func SetProviderProxy(provider, raw string) error {
+	s, err := parseSetting(raw)
+	if err != nil {
+		return err
+	}
+
+	proxyMu.Lock()
+	defer proxyMu.Unlock()
+	if s == nil {
+		delete(providerProxys, provider)
+	} else {
+		providerProxys[provider] = s
+	}
+	return nil
+}
+
+// ProxyFor returns the proxy URL requests for provider go through, nil
+// when they connect directly, and whether snoo's settings chose it. When
+// they didn't, environment proxies apply per request and are not reported.
+func ProxyFor(provider string) (u *url.URL, set bool) {
+	s := settingFor(provider)
+	if s == nil {
+		return nil, false
+	}
+	return s.url, true
+}
+
+// For returns a client for requests made on behalf of provider, so that
+// the provider's proxy override applies.
+func For(provider string) *http.Client {
+	if c, ok := providerClients.Load(provider); ok {
+		return c.(*http.Client)
+	}
+
+	c, _ := providerClients.LoadOrStore(provider, &http.Client{
+		Transport: &providerTransport{provider: provider},
+	})
+	return c.(*http.Client)
+}
+
+type providerTransport struct {
+	provider string
+}
+
+func (t *providerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := context.WithValue(req.Context(), providerKey{}, t.provider)
+	return shared.RoundTrip(req.WithContext(ctx))
+}
+
+func parseSetting(raw string) (*proxySetting, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	u, err := ParseProxy(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &proxySetting{url: u}, nil
+}
+
+// settingFor returns the provider's override, else the global proxy. Nil
+// means neither is set.
+func settingFor(provider string) *proxySetting {
+	proxyMu.RLock()
+	defer proxyMu.RUnlock()
+	if s, ok := providerProxys[provider]; ok {
+		return s
+	}
+	return defaultProxy
+}
+
+// proxyForRequest is the base transport's Proxy function.
+func proxyForRequest(req *http.Request) (*url.URL, error) {
+	provider, _ := req.Context().Value(providerKey{}).(string)
+	if s := settingFor(provider); s != nil {
+		return s.url, nil
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// checkOnion rejects .onion hosts unless they will be resolved by a SOCKS
+// proxy; anything else would leak the lookup to the local resolver.
+func checkOnion(req *http.Request) error {
+	if !strings.HasSuffix(strings.ToLower(req.URL.Hostname()), ".onion") {
+		return nil
+	}
+
+	u, err := proxyForRequest(req)
+	if err == nil && u != nil && strings.HasPrefix(u.Scheme, "socks5") {
+		return nil
+	}
+	return fmt.Errorf("%s is an onion address; configure a SOCKS5 proxy such as Tor (snoo proxy set socks5h://127.0.0.1:9050)", req.URL.Hostname())
+}
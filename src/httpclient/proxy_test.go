@@ -0,0 +1,127 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseProxy(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{"direct", "", true},
+		{" http://127.0.0.1:8080 ", "http://127.0.0.1:8080", true},
+		{"socks5h://127.0.0.1:9050", "socks5h://127.0.0.1:9050", true},
+		{"ftp://127.0.0.1", "", false},
+		{"127.0.0.1:8080", "", false},
+		{"http://", "", false},
+	}
+
+	for _, tt := range tests {
+		u, err := ParseProxy(tt.raw)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseProxy(%q) error = %v, want ok %v", tt.raw, err, tt.ok)
+			continue
+		}
+		var got string
+		if u != nil {
+			got = u.String()
+		}
+		if tt.ok && got != tt.want {
+			t.Errorf("ParseProxy(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestProxySelection(t *testing.T) {
+	t.Cleanup(func() {
+		SetProxy("")
+		SetProviderProxy("reddit", "")
+		SetProviderProxy("lobsters", "")
+	})
+
+	steps := []struct {
+		global    string
+		providers map[string]string
+		provider  string
+		want      string // "" for direct
+		set       bool
+	}{
+		{"", nil, "reddit", "", false},
+		{"http://proxy:8080", nil, "reddit", "http://proxy:8080", true},
+		{"http://proxy:8080", map[string]string{"reddit": "socks5h://tor:9050"}, "reddit", "socks5h://tor:9050", true},
+		{"http://proxy:8080", map[string]string{"reddit": "socks5h://tor:9050"}, "hackernews", "http://proxy:8080", true},
+		{"http://proxy:8080", map[string]string{"lobsters": "direct"}, "lobsters", "", true},
+		{"direct", nil, "rss", "", true},
+		{"", map[string]string{"reddit": "http://r:1"}, "rss", "", false},
+	}
+
+	for _, tt := range steps {
+		if err := SetProxy(tt.global); err != nil {
+			t.Fatalf("SetProxy(%q): %v", tt.global, err)
+		}
+		for _, p := range []string{"reddit", "lobsters"} {
+			if err := SetProviderProxy(p, tt.providers[p]); err != nil {
+				t.Fatalf("SetProviderProxy(%q): %v", p, err)
+			}
+		}
+
+		u, set := ProxyFor(tt.provider)
+		var got string
+		if u != nil {
+			got = u.String()
+		}
+		if got != tt.want || set != tt.set {
+			t.Errorf("global %q, overrides %v: ProxyFor(%q) = %q, %v; want %q, %v",
+				tt.global, tt.providers, tt.provider, got, set, tt.want, tt.set)
+		}
+
+		// Requests made for the provider go through the same proxy.
+		if tt.set {
+			req, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+			req = req.WithContext(context.WithValue(req.Context(), providerKey{}, tt.provider))
+			u, err := proxyForRequest(req)
+			got = ""
+			if u != nil {
+				got = u.String()
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("proxyForRequest for %q = %q, %v; want %q", tt.provider, got, err, tt.want)
+			}
+		}
+	}
+}
+
+func TestCheckOnion(t *testing.T) {
+	t.Cleanup(func() { SetProxy("") })
+
+	tests := []struct {
+		proxy string
+		host  string
+		ok    bool
+	}{
+		{"direct", "example.com", true},
+		{"direct", "abc.onion", false},
+		{"http://proxy:8080", "abc.onion", false},
+		{"socks5://127.0.0.1:9050", "abc.onion", true},
+		{"socks5h://127.0.0.1:9050", "ABC.ONION", true},
+	}
+
+	for _, tt := range tests {
+		if err := SetProxy(tt.proxy); err != nil {
+			t.Fatalf("SetProxy(%q): %v", tt.proxy, err)
+		}
+		req, _ := http.NewRequest(http.MethodGet, "http://"+tt.host+"/", nil)
+		err := checkOnion(req)
+		if (err == nil) != tt.ok {
+			t.Errorf("proxy %q: checkOnion(%q) = %v, want ok %v", tt.proxy, tt.host, err, tt.ok)
+		}
+		if err != nil && !strings.Contains(err.Error(), "onion") {
+			t.Errorf("checkOnion error %q doesn't explain itself", err)
+		}
+	}
+}
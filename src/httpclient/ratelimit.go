@@ -0,0 +1,127 @@
+package httpclient
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+type limit struct {
+	rate  float64 // tokens per second
+	burst float64
+}
+
+// Default limits by host suffix. Reddit throttles unauthenticated clients
+// hard and lobste.rs asks to be treated gently; the HN APIs cope with a lot.
+var defaultLimits = map[string]limit{
+	"reddit.com":                 {rate: 1, burst: 10},
+	"lobste.rs":                  {rate: 1, burst: 5},
+	"hacker-news.firebaseio.com": {rate: 50, burst: 50},
+	"hn.algolia.com":             {rate: 10, burst: 10},
+}
+
+var fallbackLimit = limit{rate: 5, burst: 10}
+
+// bucket is a token bucket. Tokens may go negative: a caller that takes a
+// token it doesn't have waits until the bucket has refilled to zero, which
+// queues concurrent callers in arrival order.
+type bucket struct {
+	mu     sync.Mutex
+	limit  limit
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(l limit) *bucket {
+	return &bucket{limit: l, tokens: l.burst, last: time.Now()}
+}
+
+// wait takes a token, sleeping until it is available. It returns how long
+// it waited.
+func (b *bucket) wait(ctx context.Context) (time.Duration, error) {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.limit.rate
+	if b.tokens > b.limit.burst {
+		b.tokens = b.limit.burst
+	}
+	b.last = now
+	b.tokens--
+
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.limit.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if delay == 0 {
+		return 0, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return delay, nil
+	case <-ctx.Done():
+		// Give the token back so cancelled callers don't slow others down.
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return 0, ctx.Err()
+	}
+}
+
+type limiters struct {
+	mu      sync.Mutex
+	limits  map[string]limit
+	buckets map[string]*bucket
+}
+
+func newLimiters() *limiters {
+	l := &limiters{
+		limits:  make(map[string]limit, len(defaultLimits)),
+		buckets: make(map[string]*bucket),
+	}
+	for host, lim := range defaultLimits {
+		l.limits[host] = lim
+	}
+	return l
+}
+
+func (l *limiters) setLimit(host string, lim limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits[strings.ToLower(host)] = lim
+	// Drop buckets built from the old limit.
+	for key := range l.buckets {
+		if key == host || strings.HasSuffix(key, "."+host) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// forHost returns the bucket for host, keyed by the most specific configured
+// suffix so e.g. www.reddit.com and oauth.reddit.com share one budget.
+func (l *limiters) forHost(host string) *bucket {
+	host = strings.ToLower(host)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key, lim := host, fallbackLimit
+	best := -1
+	for suffix, sl := range l.limits {
+		if (host == suffix || strings.HasSuffix(host, "."+suffix)) && len(suffix) > best {
+			key, lim, best = suffix, sl, len(suffix)
+		}
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = newBucket(lim)
+		l.buckets[key] = b
+	}
+	return b
+}
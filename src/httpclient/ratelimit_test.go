@@ -0,0 +1,73 @@
+package httpclient
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestForHost(t *testing.T) {
+	l := newLimiters()
+
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"www.reddit.com", "oauth.reddit.com", true},
+		{"reddit.com", "WWW.REDDIT.COM", true},
+		{"reddit.com", "notreddit.com", false},
+		{"example.com", "example.org", false},
+		{"example.com", "example.com", true},
+	}
+	for _, tt := range tests {
+		if same := l.forHost(tt.a) == l.forHost(tt.b); same != tt.same {
+			t.Errorf("forHost(%q) and forHost(%q) shared = %v, want %v", tt.a, tt.b, same, tt.same)
+		}
+	}
+
+	if got := l.forHost("www.reddit.com").limit; got != defaultLimits["reddit.com"] {
+		t.Errorf("reddit limit = %+v, want %+v", got, defaultLimits["reddit.com"])
+	}
+	if got := l.forHost("example.com").limit; got != fallbackLimit {
+		t.Errorf("example.com limit = %+v, want the fallback %+v", got, fallbackLimit)
+	}
+
+	old := l.forHost("api.example.com")
+	l.setLimit("example.com", limit{rate: 1, burst: 1})
+	if b := l.forHost("api.example.com"); b == old || b.limit.rate != 1 {
+		t.Errorf("after setLimit, api.example.com has limit %+v", b.limit)
+	}
+	if l.forHost("www.reddit.com") != l.forHost("reddit.com") {
+		t.Error("setLimit on another host dropped reddit's bucket")
+	}
+}
+
+func TestBucketWait(t *testing.T) {
+	b := newBucket(limit{rate: 20, burst: 2})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if waited, err := b.wait(ctx); err != nil || waited != 0 {
+			t.Fatalf("burst request %d waited %v, %v", i, waited, err)
+		}
+	}
+
+	waited, err := b.wait(ctx)
+	if err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if waited <= 0 || waited > 50*time.Millisecond {
+		t.Errorf("request past the burst waited %v, want about 50ms", waited)
+	}
+
+	// A cancelled caller gives its token back.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	before := b.tokens
+	if _, err := b.wait(cancelled); err == nil {
+		t.Error("wait with a cancelled context succeeded")
+	}
+	if b.tokens < before {
+		t.Errorf("tokens went from %v to %v after a cancelled wait", before, b.tokens)
+	}
+}
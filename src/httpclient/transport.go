@@ -0,0 +1,161 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/snoofox/snoo/src/debug"
+)
+
+const (
+	baseBackoff   = 500 * time.Millisecond
+	maxBackoff    = 30 * time.Second
+	maxRetryAfter = 60 * time.Second
+)
+
+type transport struct {
+	base       http.RoundTripper
+	limiters   *limiters
+	maxRetries int
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(ctx)
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", UserAgent())
+	}
+	if err := checkOnion(req); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	var waited time.Duration
+	bucket := t.limiters.forHost(req.URL.Hostname())
+
+	for attempt := 0; ; attempt++ {
+		w, err := bucket.wait(ctx)
+		waited += w
+		if err != nil {
+			return nil, err
+		}
+
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(req)
+
+		canRetry := attempt < t.maxRetries && (req.Body == nil || req.GetBody != nil)
+		if !canRetry || !shouldRetry(ctx, resp, err) {
+			logRequest(req, resp, err, attempt, waited, time.Since(start))
+			return resp, err
+		}
+
+		delay := backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp); ok {
+				delay = after
+			}
+			debug.Log("HTTP %s %s: status %d, retrying in %v", req.Method, req.URL.Host, resp.StatusCode, delay.Round(time.Millisecond))
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		} else {
+			debug.Log("HTTP %s %s: %v, retrying in %v", req.Method, req.URL.Host, err, delay.Round(time.Millisecond))
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		waited += delay
+	}
+}
+
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff is exponential with full jitter on the upper half, so parallel
+// fetches that failed together don't retry in lockstep.
+func backoff(attempt int) time.Duration {
+	d := baseBackoff << attempt
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryAfter parses the Retry-After header, which holds either seconds or
+// an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	var d time.Duration
+	if secs, err := strconv.Atoi(value); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		d = time.Until(at)
+	} else {
+		return 0, false
+	}
+
+	if d < 0 {
+		d = 0
+	}
+	if d > maxRetryAfter {
+		d = maxRetryAfter
+	}
+	return d, true
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func logRequest(req *http.Request, resp *http.Response, err error, retries int, waited, took time.Duration) {
+	var status string
+	if err != nil {
+		status = err.Error()
+	} else {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+
+	debug.Log("HTTP %s %s%s -> %s (%v, retries %d, waited %v)",
+		req.Method, req.URL.Host, req.URL.Path, status,
+		took.Round(time.Millisecond), retries, waited.Round(time.Millisecond))
+}
@@ -0,0 +1,169 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"0", 0, true},
+		{"-5", 0, true},
+		{"3600", maxRetryAfter, true},
+		{"Mon, 02 Jan 2006 15:04:05 GMT", 0, true},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.value != "" {
+			resp.Header.Set("Retry-After", tt.value)
+		}
+		got, ok := retryAfter(resp)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Retry-After", time.Now().Add(10*time.Second).UTC().Format(http.TimeFormat))
+	if got, ok := retryAfter(resp); !ok || got <= 8*time.Second || got > 10*time.Second {
+		t.Errorf("retryAfter(10s from now) = %v, %v", got, ok)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		d := min(baseBackoff<<attempt, maxBackoff)
+		for i := 0; i < 20; i++ {
+			if got := backoff(attempt); got < d/2 || got > d {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", attempt, got, d/2, d)
+			}
+		}
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		status int
+		err    error
+		want   bool
+	}{
+		{"ok", context.Background(), http.StatusOK, nil, false},
+		{"not found", context.Background(), http.StatusNotFound, nil, false},
+		{"too many requests", context.Background(), http.StatusTooManyRequests, nil, true},
+		{"server error", context.Background(), http.StatusInternalServerError, nil, true},
+		{"unavailable", context.Background(), http.StatusServiceUnavailable, nil, true},
+		{"not implemented", context.Background(), http.StatusNotImplemented, nil, false},
+		{"network error", context.Background(), 0, errors.New("connection reset"), true},
+		{"cancelled", cancelled, http.StatusServiceUnavailable, nil, false},
+	}
+
+	for _, tt := range tests {
+		var resp *http.Response
+		if tt.err == nil {
+			resp = &http.Response{StatusCode: tt.status}
+		}
+		if got := shouldRetry(tt.ctx, resp, tt.err); got != tt.want {
+			t.Errorf("%s: shouldRetry = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTransportRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int // responses that fail before one succeeds
+		status   int // status of the failures
+		attempts int32
+		want     int
+	}{
+		{"no failure", 0, 0, 1, http.StatusOK},
+		{"recovers", 2, http.StatusServiceUnavailable, 3, http.StatusOK},
+		{"rate limited", 1, http.StatusTooManyRequests, 2, http.StatusOK},
+		{"gives up", 10, http.StatusBadGateway, 4, http.StatusBadGateway},
+		{"not retried", 10, http.StatusNotFound, 1, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := attempts.Add(1)
+				if body, _ := io.ReadAll(r.Body); string(body) != "payload" {
+					t.Errorf("attempt %d got body %q", n, body)
+				}
+				if r.Header.Get("User-Agent") != UserAgent() {
+					t.Errorf("attempt %d got User-Agent %q", n, r.Header.Get("User-Agent"))
+				}
+				if int(n) <= tt.failures {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: &transport{
+				base:       http.DefaultTransport,
+				limiters:   newLimiters(),
+				maxRetries: 3,
+			}}
+			resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+			if err != nil {
+				t.Fatalf("Post: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Errorf("status %d, want %d", resp.StatusCode, tt.want)
+			}
+			if n := attempts.Load(); n != tt.attempts {
+				t.Errorf("%d attempts, want %d", n, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestTransportStopsWithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+
+	client := &http.Client{Transport: &transport{base: http.DefaultTransport, limiters: newLimiters(), maxRetries: 3}}
+	start := time.Now()
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do error = %v, want the context's", err)
+	}
+	if took := time.Since(start); took > 5*time.Second {
+		t.Errorf("Do took %v waiting on Retry-After past the deadline", took)
+	}
+}
@@ -0,0 +1,114 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/snoofox/snoo/src/debug"
+	"github.com/snoofox/snoo/src/httpclient"
+)
+
+// Progress is called while downloading with the bytes written so far and
+// the total size, which is 0 when the server doesn't report it.
+type Progress func(written, total int64)
+
+// FileName derives a local file name from a media URL.
+func FileName(mediaURL string) string {
+	name := "download"
+	if u, err := url.Parse(mediaURL); err == nil {
+		if base := path.Base(u.Path); base != "" && base != "/" && base != "." {
+			name = base
+		}
+	}
+	return name
+}
+
+// Download saves mediaURL to dest. Data is written to dest+".part" first so
+// an interrupted download resumes from where it stopped on the next call.
+func Download(ctx context.Context, mediaURL, dest string, progress Progress) error {
+	partPath := dest + ".part"
+
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", mediaURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		debug.Log("Media: Resuming %s at byte %d", mediaURL, offset)
+	}
+
+	resp, err := httpclient.Streaming.Do(req)
+	if err != nil {
+		return fmt.Errorf("error fetching media: %w", err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		// Server ignored the range request, start over.
+		offset = 0
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The part file already holds everything.
+		return os.Rename(partPath, dest)
+	default:
+		return fmt.Errorf("HTTP status %d", resp.StatusCode)
+	}
+
+	total := int64(0)
+	if resp.ContentLength > 0 {
+		total = offset + resp.ContentLength
+	} else if cr := resp.Header.Get("Content-Range"); cr != "" {
+		if i := strings.LastIndex(cr, "/"); i != -1 {
+			total, _ = strconv.ParseInt(cr[i+1:], 10, 64)
+		}
+	}
+
+	f, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening file: %w", err)
+	}
+
+	w := &progressWriter{w: f, written: offset, total: total, progress: progress}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		f.Close()
+		return fmt.Errorf("error downloading media: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error writing file: %w", err)
+	}
+
+	debug.Log("Media: Downloaded %s to %s (%d bytes)", mediaURL, dest, w.written)
+	return os.Rename(partPath, dest)
+}
+
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	progress Progress
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	if p.progress != nil {
+		p.progress(p.written, p.total)
+	}
+	return n, err
+}
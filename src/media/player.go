@@ -0,0 +1,21 @@
+package media
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const defaultPlayer = "mpv"
+
+// PlayerCommand builds the command that plays mediaURL. The player is taken
+// from $SNOO_PLAYER (which may include arguments) and defaults to mpv.
+func PlayerCommand(mediaURL string) *exec.Cmd {
+	player := strings.Fields(os.Getenv("SNOO_PLAYER"))
+	if len(player) == 0 {
+		player = []string{defaultPlayer}
+	}
+
+	args := append(player[1:], mediaURL)
+	return exec.Command(player[0], args...)
+}
@@ -0,0 +1,72 @@
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LegacyDir is where versions before XDG support kept everything.
+func LegacyDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".snoo"), nil
+}
+
+// MigrateLegacy moves the contents of ~/.snoo into the XDG directories:
+// the log to the state directory and everything else, including the
+// database and plugins, to the data directory. Files that already exist
+// at their destination are left in place. ~/.snoo is removed once empty,
+// so this does nothing after the first run.
+func MigrateLegacy() error {
+	legacy, err := LegacyDir()
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(legacy)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	dataDir, err := DataDir()
+	if err != nil {
+		return err
+	}
+	stateDir, err := StateDir()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		dir := dataDir
+		if entry.Name() == "debug.log" {
+			dir = stateDir
+		}
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+
+		dest := filepath.Join(dir, entry.Name())
+		if _, err := os.Lstat(dest); err == nil {
+			errs = append(errs, fmt.Errorf("not moving %s: %s already exists", entry.Name(), dest))
+			continue
+		}
+		if err := os.Rename(filepath.Join(legacy, entry.Name()), dest); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Only succeeds once everything has moved.
+	os.Remove(legacy)
+
+	return errors.Join(errs...)
+}
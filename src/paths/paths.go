@@ -0,0 +1,43 @@
+// Package paths locates snoo's files following the XDG base directory
+// spec: configuration in $XDG_CONFIG_HOME/snoo, the database and plugins
+// in $XDG_DATA_HOME/snoo and logs in $XDG_STATE_HOME/snoo. snoo keeps no
+// cache data: cached posts carry read and saved state, so they live in the
+// database.
+package paths
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const appName = "snoo"
+
+// ConfigDir returns $XDG_CONFIG_HOME/snoo, by default ~/.config/snoo.
+func ConfigDir() (string, error) {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// DataDir returns $XDG_DATA_HOME/snoo, by default ~/.local/share/snoo.
+func DataDir() (string, error) {
+	return xdgDir("XDG_DATA_HOME", ".local", "share")
+}
+
+// StateDir returns $XDG_STATE_HOME/snoo, by default ~/.local/state/snoo.
+func StateDir() (string, error) {
+	return xdgDir("XDG_STATE_HOME", ".local", "state")
+}
+
+// xdgDir returns the snoo directory under the base directory named by env,
+// or under the home directory fallback when env is unset. The spec says
+// relative values are invalid and must be ignored.
+func xdgDir(env string, fallback ...string) (string, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appName), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append(append([]string{homeDir}, fallback...), appName)...), nil
+}
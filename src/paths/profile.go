@@ -0,0 +1,79 @@
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// DefaultProfile is the profile used when none is selected. Its files live
+// directly in the snoo directories; other profiles live in a profiles/<name>
+// subdirectory of each.
+const DefaultProfile = "default"
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// CheckProfile reports whether name is usable as a profile name.
+func CheckProfile(name string) error {
+	if !profileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, - and _", name)
+	}
+	return nil
+}
+
+// ProfileConfigDir returns the directory holding the profile's config file.
+func ProfileConfigDir(profile string) (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return profileDir(dir, profile), nil
+}
+
+// ProfileDataDir returns the directory holding the profile's database.
+func ProfileDataDir(profile string) (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return profileDir(dir, profile), nil
+}
+
+func profileDir(base, profile string) string {
+	if profile == "" || profile == DefaultProfile {
+		return base
+	}
+	return filepath.Join(base, "profiles", profile)
+}
+
+// Profiles lists the default profile and every profile that has a config
+// or data directory, sorted by name.
+func Profiles() ([]string, error) {
+	found := map[string]bool{DefaultProfile: true}
+
+	for _, base := range []func() (string, error){ConfigDir, DataDir} {
+		dir, err := base()
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := os.ReadDir(filepath.Join(dir, "profiles"))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && CheckProfile(entry.Name()) == nil {
+				found[entry.Name()] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,92 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/snoofox/snoo/src/debug"
+	"github.com/snoofox/snoo/src/feed"
+	"github.com/snoofox/snoo/src/paths"
+)
+
+// Prefix is the file name prefix that marks an executable as a snoo
+// provider plugin, e.g. snoo-provider-mastodon.
+const Prefix = "snoo-provider-"
+
+// Dir is the directory searched for plugins before $PATH.
+func Dir() (string, error) {
+	dataDir, err := paths.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "plugins"), nil
+}
+
+// Discover returns the paths of all plugin executables, keyed by file name.
+// Plugins in Dir shadow those of the same name found on $PATH.
+func Discover() map[string]string {
+	found := make(map[string]string)
+
+	dirs := filepath.SplitList(os.Getenv("PATH"))
+	if dir, err := Dir(); err == nil {
+		dirs = append([]string{dir}, dirs...)
+	}
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, Prefix) || entry.IsDir() {
+				continue
+			}
+			if _, ok := found[name]; ok {
+				continue
+			}
+
+			path := filepath.Join(dir, name)
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+				continue
+			}
+			found[name] = path
+		}
+	}
+
+	return found
+}
+
+// RegisterAll loads every discovered plugin and registers it as a feed
+// provider. It is meant to be given to feed.RegisterLoader, so plugins only
+// start when a command needs a provider. Plugins that fail to start, or
+// that would replace a provider that is already registered, are skipped
+// and logged.
+func RegisterAll(ctx context.Context) {
+	found := Discover()
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p, err := Load(ctx, found[name])
+		if err != nil {
+			debug.Log("Plugin %s: %v", name, err)
+			continue
+		}
+
+		if !feed.RegisterNew(p) {
+			debug.Log("Plugin %s: provider type %q is already registered, skipping", name, p.Type())
+			continue
+		}
+		debug.Log("Plugin %s: registered provider %q", name, p.Type())
+	}
+}
@@ -0,0 +1,184 @@
+package plugin
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/snoofox/snoo/src/feed"
+)
+
+// ProtocolVersion is bumped whenever the wire format changes incompatibly.
+// Plugins report the version they speak in their "type" response.
+const ProtocolVersion = 1
+
+// Methods a plugin must answer.
+const (
+	methodType           = "type"
+	methodValidateSource = "validate_source"
+	methodFetchPosts     = "fetch_posts"
+	methodFetchComments  = "fetch_comments"
+)
+
+// request is a JSON-RPC 2.0 request, written to the plugin's stdin as a
+// single line.
+type request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int         `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// response is the JSON-RPC 2.0 response the plugin writes to stdout.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int             `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type typeResult struct {
+	Type            string `json:"type"`
+	ProtocolVersion int    `json:"protocol_version"`
+	HasComments     bool   `json:"has_comments"`
+}
+
+type validateParams struct {
+	Identifier string `json:"identifier"`
+}
+
+type sourceMetadata struct {
+	Name        string                 `json:"name"`
+	DisplayName string                 `json:"display_name"`
+	Description string                 `json:"description"`
+	IconURL     string                 `json:"icon_url"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type source struct {
+	ID          uint                   `json:"id"`
+	Identifier  string                 `json:"identifier"`
+	Name        string                 `json:"name"`
+	DisplayName string                 `json:"display_name"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	LastFetchAt *time.Time             `json:"last_fetch_at,omitempty"`
+}
+
+type fetchPostsParams struct {
+	Source source `json:"source"`
+}
+
+type enclosure struct {
+	URL      string `json:"url"`
+	Type     string `json:"type"`
+	Length   int64  `json:"length"`
+	Duration int    `json:"duration"` // seconds
+}
+
+type post struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Author      string     `json:"author"`
+	SourceName  string     `json:"source_name"`
+	Permalink   string     `json:"permalink"`
+	URL         string     `json:"url"`
+	Score       int        `json:"score"`
+	NumComments int        `json:"num_comments"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Content     string     `json:"content"`
+	Thumbnail   string     `json:"thumbnail"`
+	NSFW        bool       `json:"nsfw"`
+	Tags        []string   `json:"tags,omitempty"`
+	CommentsURL string     `json:"comments_url,omitempty"`
+	Enclosure   *enclosure `json:"enclosure,omitempty"`
+}
+
+type fetchCommentsParams struct {
+	Post post `json:"post"`
+}
+
+type comment struct {
+	ID        string    `json:"id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	Score     int       `json:"score"`
+	CreatedAt time.Time `json:"created_at"`
+	Replies   []comment `json:"replies,omitempty"`
+}
+
+func toWirePost(p feed.Post) post {
+	w := post{
+		ID:          p.ID,
+		Title:       p.Title,
+		Author:      p.Author,
+		SourceName:  p.SourceName,
+		Permalink:   p.Permalink,
+		URL:         p.URL,
+		Score:       p.Score,
+		NumComments: p.NumComments,
+		CreatedAt:   p.CreatedAt,
+		Content:     p.Content,
+		Thumbnail:   p.Thumbnail,
+		NSFW:        p.NSFW,
+		Tags:        p.Tags,
+		CommentsURL: p.CommentsURL,
+	}
+	if p.Enclosure != nil {
+		w.Enclosure = &enclosure{
+			URL:      p.Enclosure.URL,
+			Type:     p.Enclosure.Type,
+			Length:   p.Enclosure.Length,
+			Duration: int(p.Enclosure.Duration.Seconds()),
+		}
+	}
+	return w
+}
+
+func (w post) toFeed(sourceType string) feed.Post {
+	p := feed.Post{
+		ID:          w.ID,
+		Title:       w.Title,
+		Author:      w.Author,
+		SourceName:  w.SourceName,
+		SourceType:  sourceType,
+		Permalink:   w.Permalink,
+		URL:         w.URL,
+		Score:       w.Score,
+		NumComments: w.NumComments,
+		CreatedAt:   w.CreatedAt,
+		Content:     w.Content,
+		Thumbnail:   w.Thumbnail,
+		NSFW:        w.NSFW,
+		Tags:        w.Tags,
+		CommentsURL: w.CommentsURL,
+	}
+	if w.Enclosure != nil && w.Enclosure.URL != "" {
+		p.Enclosure = &feed.Enclosure{
+			URL:      w.Enclosure.URL,
+			Type:     w.Enclosure.Type,
+			Length:   w.Enclosure.Length,
+			Duration: time.Duration(w.Enclosure.Duration) * time.Second,
+		}
+	}
+	return p
+}
+
+func (w comment) toFeed(depth int) feed.Comment {
+	c := feed.Comment{
+		ID:        w.ID,
+		Author:    w.Author,
+		Body:      w.Body,
+		Score:     w.Score,
+		CreatedAt: w.CreatedAt,
+		Depth:     depth,
+		Replies:   make([]feed.Comment, 0, len(w.Replies)),
+	}
+	for _, r := range w.Replies {
+		c.Replies = append(c.Replies, r.toFeed(depth+1))
+	}
+	return c
+}
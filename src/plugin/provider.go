@@ -0,0 +1,240 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/snoofox/snoo/src/debug"
+	"github.com/snoofox/snoo/src/feed"
+	"github.com/snoofox/snoo/src/httpclient"
+)
+
+const (
+	handshakeTimeout = 5 * time.Second
+	callTimeout      = 30 * time.Second
+)
+
+// requestIDs numbers requests, so a response can be matched to its request.
+var requestIDs atomic.Int64
+
+// Provider implements feed.Provider by running an external executable and
+// exchanging one JSON-RPC request and response per call over its stdin and
+// stdout. Anything the plugin writes to stderr goes to the debug log.
+type Provider struct {
+	path        string
+	typ         string
+	hasComments bool
+}
+
+// Load starts the plugin at path once to learn its provider type and check
+// that it speaks a compatible protocol version.
+func Load(ctx context.Context, path string) (*Provider, error) {
+	p := &Provider{path: path}
+
+	ctx, cancel := context.WithTimeout(ctx, handshakeTimeout)
+	defer cancel()
+
+	var result typeResult
+	if err := p.call(ctx, methodType, nil, &result); err != nil {
+		return nil, err
+	}
+
+	if result.ProtocolVersion != ProtocolVersion {
+		return nil, fmt.Errorf("plugin %s speaks protocol version %d, snoo speaks %d",
+			filepath.Base(path), result.ProtocolVersion, ProtocolVersion)
+	}
+	if result.Type == "" {
+		return nil, fmt.Errorf("plugin %s reported an empty type", filepath.Base(path))
+	}
+
+	p.typ = result.Type
+	p.hasComments = result.HasComments
+	return p, nil
+}
+
+func (p *Provider) Type() string {
+	return p.typ
+}
+
+// HasComments reports whether the plugin serves comments.
+func (p *Provider) HasComments() bool {
+	return p.hasComments
+}
+
+func (p *Provider) FetchPosts(ctx context.Context, src feed.Source) ([]feed.Post, error) {
+	params := fetchPostsParams{Source: source{
+		ID:          src.ID,
+		Identifier:  src.Identifier,
+		Name:        src.Name,
+		DisplayName: src.DisplayName,
+		Metadata:    src.Metadata,
+		LastFetchAt: src.LastFetchAt,
+	}}
+
+	var result []post
+	if err := p.callWithTimeout(ctx, methodFetchPosts, params, &result); err != nil {
+		return nil, err
+	}
+
+	posts := make([]feed.Post, 0, len(result))
+	for _, w := range result {
+		posts = append(posts, w.toFeed(p.typ))
+	}
+	return posts, nil
+}
+
+func (p *Provider) FetchComments(ctx context.Context, fp feed.Post) ([]feed.Comment, error) {
+	if !p.hasComments {
+		return []feed.Comment{}, nil
+	}
+
+	var result []comment
+	if err := p.callWithTimeout(ctx, methodFetchComments, fetchCommentsParams{Post: toWirePost(fp)}, &result); err != nil {
+		return nil, err
+	}
+
+	comments := make([]feed.Comment, 0, len(result))
+	for _, w := range result {
+		comments = append(comments, w.toFeed(0))
+	}
+	return comments, nil
+}
+
+func (p *Provider) ValidateSource(ctx context.Context, identifier string) (*feed.SourceMetadata, error) {
+	var result sourceMetadata
+	if err := p.callWithTimeout(ctx, methodValidateSource, validateParams{Identifier: identifier}, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Name == "" {
+		result.Name = identifier
+	}
+	if result.DisplayName == "" {
+		result.DisplayName = result.Name
+	}
+
+	return &feed.SourceMetadata{
+		Name:        result.Name,
+		DisplayName: result.DisplayName,
+		Description: result.Description,
+		IconURL:     result.IconURL,
+		Metadata:    result.Metadata,
+	}, nil
+}
+
+func (p *Provider) callWithTimeout(ctx context.Context, method string, params, result interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+	return p.call(ctx, method, params, result)
+}
+
+// call runs the plugin for a single request. The process is killed when ctx
+// is done.
+func (p *Provider) call(ctx context.Context, method string, params, result interface{}) error {
+	name := filepath.Base(p.path)
+
+	id := int(requestIDs.Add(1))
+	payload, err := json.Marshal(request{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("error encoding request: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, p.path)
+	cmd.WaitDelay = time.Second
+	cmd.Env = proxyEnv(p.typ)
+	cmd.Stdin = bytes.NewReader(append(payload, '\n'))
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("error starting plugin %s: %w", name, err)
+	}
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("error starting plugin %s: %w", name, err)
+	}
+
+	logStderr(name, stderr)
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("plugin %s %s: %w", name, method, ctx.Err())
+		}
+		return fmt.Errorf("plugin %s %s failed: %w", name, method, err)
+	}
+
+	debug.Log("Plugin %s: %s took %v", name, method, time.Since(start))
+
+	line, _, _ := bytes.Cut(bytes.TrimSpace(stdout.Bytes()), []byte("\n"))
+
+	var resp response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return fmt.Errorf("plugin %s sent an invalid response: %w", name, err)
+	}
+	if resp.ID != id {
+		return fmt.Errorf("plugin %s answered request %d instead of %d", name, resp.ID, id)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("plugin %s: %s", name, resp.Error.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("plugin %s sent an invalid %s result: %w", name, method, err)
+	}
+	return nil
+}
+
+func logStderr(name string, r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		debug.Log("Plugin %s: %s", name, scanner.Text())
+	}
+}
+
+// proxyVars are the environment variables HTTP clients read proxies from,
+// besides NO_PROXY.
+var proxyVars = []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY"}
+
+// proxyEnv passes snoo's proxy for the plugin's provider type on through
+// the standard environment variables, replacing any inherited ones. A
+// direct setting clears them and sets NO_PROXY=*. It returns nil, meaning
+// the inherited environment, when no proxy is configured.
+func proxyEnv(typ string) []string {
+	u, set := httpclient.ProxyFor(typ)
+	if !set {
+		return nil
+	}
+
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		name = strings.ToUpper(name)
+		if name != "NO_PROXY" && !slices.Contains(proxyVars, name) {
+			env = append(env, kv)
+		}
+	}
+
+	if u == nil {
+		return append(env, "NO_PROXY=*", "no_proxy=*")
+	}
+	for _, name := range proxyVars {
+		env = append(env, name+"="+u.String(), strings.ToLower(name)+"="+u.String())
+	}
+	return env
+}
@@ -0,0 +1,162 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/snoofox/snoo/src/feed"
+	"github.com/snoofox/snoo/src/httpclient"
+)
+
+// fakePlugin answers each method the way a plugin would. FAKE_ID overrides
+// the id it answers with and FAKE_VERSION the protocol version it reports.
+const fakePlugin = `#!/bin/sh
+read -r line
+id=$(printf '%s' "$line" | sed -n 's/^{"jsonrpc":"2.0","id":\([0-9]*\).*/\1/p')
+[ -n "$FAKE_ID" ] && id=$FAKE_ID
+identifier=$(printf '%s' "$line" | sed -n 's/.*"identifier":"\([^"]*\)".*/\1/p')
+echo "handling $line" >&2
+case "$line" in
+*'"method":"type"'*)
+	result="{\"type\":\"fake\",\"protocol_version\":${FAKE_VERSION:-1},\"has_comments\":true}" ;;
+*'"method":"validate_source"'*)
+	result="{\"name\":\"fake/$identifier\",\"metadata\":{\"k\":\"v\"}}" ;;
+*'"method":"fetch_posts"'*)
+	result="[{\"id\":\"1\",\"title\":\"$identifier\",\"score\":3,\"enclosure\":{\"url\":\"https://example.com/a.mp3\",\"duration\":90}}]" ;;
+*'"method":"fetch_comments"'*)
+	result='[{"id":"c1","body":"hi","replies":[{"id":"c2","body":"re"}]}]' ;;
+*)
+	printf '{"jsonrpc":"2.0","id":%s,"error":{"code":-32601,"message":"no such method"}}\n' "$id"
+	exit 0 ;;
+esac
+printf '{"jsonrpc":"2.0","id":%s,"result":%s}\n' "$id" "$result"
+`
+
+func writePlugin(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), Prefix+"fake")
+	if err := os.WriteFile(path, []byte(fakePlugin), 0o755); err != nil {
+		t.Fatalf("write plugin: %v", err)
+	}
+	return path
+}
+
+func TestProtocol(t *testing.T) {
+	ctx := context.Background()
+	p, err := Load(ctx, writePlugin(t))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if p.Type() != "fake" || !p.HasComments() {
+		t.Errorf("Load = type %q, comments %v; want fake, true", p.Type(), p.HasComments())
+	}
+
+	meta, err := p.ValidateSource(ctx, "news")
+	if err != nil {
+		t.Fatalf("ValidateSource: %v", err)
+	}
+	if meta.Name != "fake/news" || meta.DisplayName != "fake/news" || meta.Metadata["k"] != "v" {
+		t.Errorf("ValidateSource = %+v", meta)
+	}
+
+	posts, err := p.FetchPosts(ctx, feed.Source{Identifier: "news"})
+	if err != nil {
+		t.Fatalf("FetchPosts: %v", err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("FetchPosts returned %d posts, want 1", len(posts))
+	}
+	if got := posts[0]; got.Title != "news" || got.SourceType != "fake" || got.Score != 3 ||
+		got.Enclosure == nil || got.Enclosure.Duration != 90*time.Second {
+		t.Errorf("FetchPosts = %+v", got)
+	}
+
+	comments, err := p.FetchComments(ctx, posts[0])
+	if err != nil {
+		t.Fatalf("FetchComments: %v", err)
+	}
+	if len(comments) != 1 || len(comments[0].Replies) != 1 || comments[0].Replies[0].Depth != 1 {
+		t.Errorf("FetchComments = %+v", comments)
+	}
+}
+
+func TestProtocolErrors(t *testing.T) {
+	path := writePlugin(t)
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"wrong id", map[string]string{"FAKE_ID": "0"}, "answered request 0"},
+		{"wrong version", map[string]string{"FAKE_VERSION": "2"}, "protocol version 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load(context.Background(), path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+
+	p, err := Load(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	err = p.call(context.Background(), "nope", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "no such method") {
+		t.Errorf("unknown method error = %v", err)
+	}
+}
+
+func TestProxyEnv(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://inherited:8080")
+	t.Setenv("http_proxy", "http://inherited:8080")
+	t.Setenv("NO_PROXY", "example.com")
+
+	tests := []struct {
+		proxy   string
+		set     []string
+		cleared []string
+	}{
+		{"", nil, nil},
+		{"direct", []string{"NO_PROXY=*", "no_proxy=*"}, []string{"HTTPS_PROXY", "http_proxy"}},
+		{"socks5h://127.0.0.1:9050", []string{"HTTPS_PROXY=socks5h://127.0.0.1:9050", "http_proxy=socks5h://127.0.0.1:9050"}, []string{"NO_PROXY"}},
+	}
+	for _, tt := range tests {
+		if err := httpclient.SetProviderProxy("fake", tt.proxy); err != nil {
+			t.Fatalf("SetProviderProxy(%q): %v", tt.proxy, err)
+		}
+
+		env := proxyEnv("fake")
+		if tt.proxy == "" {
+			if env != nil {
+				t.Errorf("proxy %q: got an environment, want the inherited one", tt.proxy)
+			}
+			continue
+		}
+		for _, kv := range tt.set {
+			if !slices.Contains(env, kv) {
+				t.Errorf("proxy %q: environment lacks %s", tt.proxy, kv)
+			}
+		}
+		for _, name := range tt.cleared {
+			for _, kv := range env {
+				if strings.HasPrefix(kv, name+"=") && !slices.Contains(tt.set, kv) {
+					t.Errorf("proxy %q: environment still has %s", tt.proxy, kv)
+				}
+			}
+		}
+	}
+	httpclient.SetProviderProxy("fake", "")
+}
@@ -0,0 +1,263 @@
+package hackernews
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snoofox/snoo/src/feed"
+)
+
+const algoliaURL = "https://hn.algolia.com/api/v1"
+
+type algoliaHit struct {
+	ObjectID    string `json:"objectID"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Author      string `json:"author"`
+	Points      int    `json:"points"`
+	NumComments int    `json:"num_comments"`
+	StoryText   string `json:"story_text"`
+	CreatedAtI  int64  `json:"created_at_i"`
+}
+
+type algoliaResponse struct {
+	Hits []algoliaHit `json:"hits"`
+}
+
+// algoliaItem is a node of the tree returned by the items endpoint. Deleted
+// comments come back with a null author and text.
+type algoliaItem struct {
+	ID         int           `json:"id"`
+	Type       string        `json:"type"`
+	Author     *string       `json:"author"`
+	Text       *string       `json:"text"`
+	Points     *int          `json:"points"`
+	CreatedAtI int64         `json:"created_at_i"`
+	Children   []algoliaItem `json:"children"`
+}
+
+// query is a parsed HackerNews identifier. Besides the fixed categories
+// (top, new, ...) the Algolia search API backs:
+//
+//	search:<query>    stories matching a query, newest first
+//	user:<name>       stories submitted by a user
+//	front:<date>      the front page of a past day (YYYY-MM-DD)
+//
+// Any identifier may end in ";points>=N" to drop stories below N points.
+// Only that exact suffix is read as a threshold, so queries may contain
+// semicolons of their own.
+type query struct {
+	kind      string // category name, or "search", "user", "front"
+	value     string
+	minPoints int
+}
+
+func parseQuery(identifier string) (query, error) {
+	var q query
+
+	base := identifier
+	if i := strings.LastIndex(identifier, ";points>="); i != -1 {
+		threshold := identifier[i+1:]
+		points, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(threshold, "points>=")))
+		if err != nil || points < 0 {
+			return q, fmt.Errorf("invalid points threshold %q (use ;points>=N)", threshold)
+		}
+		base, q.minPoints = identifier[:i], points
+	}
+
+	kind, value, found := strings.Cut(base, ":")
+	if !found {
+		q.kind = base
+		return q, nil
+	}
+
+	q.kind = kind
+	q.value = strings.TrimSpace(value)
+	switch kind {
+	case "search", "user":
+		if q.value == "" {
+			return q, fmt.Errorf("%s needs a value, e.g. %s:golang", kind, kind)
+		}
+	case "front":
+		if _, err := time.Parse("2006-01-02", q.value); err != nil {
+			return q, fmt.Errorf("front page date must look like 2006-01-02")
+		}
+	default:
+		return q, fmt.Errorf("unknown HackerNews feed: %s", kind)
+	}
+	return q, nil
+}
+
+func (q query) isAlgolia() bool {
+	return q.kind == "search" || q.kind == "user" || q.kind == "front"
+}
+
+func (q query) String() string {
+	s := q.kind
+	if q.value != "" {
+		s += ":" + q.value
+	}
+	if q.minPoints > 0 {
+		s += fmt.Sprintf(";points>=%d", q.minPoints)
+	}
+	return s
+}
+
+// sourceName labels posts in the feed. It carries the whole identifier so
+// sources that differ only in their threshold stay apart. The list view
+// hides everything after the last colon, so a name that has one of its own
+// gets an empty suffix to keep it whole.
+func (q query) sourceName() string {
+	s := "HackerNews/" + q.kind
+	if q.value != "" {
+		s += "/" + q.value
+	}
+	if q.minPoints > 0 {
+		s += fmt.Sprintf(";points>=%d", q.minPoints)
+	}
+	if strings.Contains(s, ":") {
+		s += ":"
+	}
+	return s
+}
+
+// algoliaURL builds the search request for an Algolia-backed query.
+func (q query) algoliaURL() string {
+	params := url.Values{}
+	params.Set("hitsPerPage", "30")
+
+	var filters []string
+	if q.minPoints > 0 {
+		filters = append(filters, fmt.Sprintf("points>=%d", q.minPoints))
+	}
+
+	endpoint := "search_by_date"
+	switch q.kind {
+	case "search":
+		params.Set("query", q.value)
+		params.Set("tags", "story")
+	case "user":
+		params.Set("tags", "story,author_"+q.value)
+	case "front":
+		day, _ := time.Parse("2006-01-02", q.value)
+		filters = append(filters,
+			fmt.Sprintf("created_at_i>=%d", day.Unix()),
+			fmt.Sprintf("created_at_i<%d", day.Add(24*time.Hour).Unix()))
+		params.Set("tags", "story")
+		// Plain search ranks by popularity, which is what a front page is.
+		endpoint = "search"
+	}
+
+	if len(filters) > 0 {
+		params.Set("numericFilters", strings.Join(filters, ","))
+	}
+	return fmt.Sprintf("%s/%s?%s", algoliaURL, endpoint, params.Encode())
+}
+
+func (p *Provider) fetchAlgolia(ctx context.Context, q query) ([]*hnItem, error) {
+	resp, err := get(ctx, q.algoliaURL())
+	if err != nil {
+		return nil, fmt.Errorf("error searching HackerNews: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("algolia returned status %d", resp.StatusCode)
+	}
+
+	var result algoliaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error decoding search results: %w", err)
+	}
+
+	items := make([]*hnItem, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		id, err := strconv.Atoi(hit.ObjectID)
+		if err != nil {
+			continue
+		}
+		items = append(items, &hnItem{
+			ID:          id,
+			Type:        "story",
+			By:          hit.Author,
+			Time:        hit.CreatedAtI,
+			Text:        hit.StoryText,
+			URL:         hit.URL,
+			Score:       hit.Points,
+			Title:       hit.Title,
+			Descendants: hit.NumComments,
+		})
+	}
+
+	return items, nil
+}
+
+// userExists checks a user name against the Firebase API, which answers
+// null for unknown users.
+func (p *Provider) userExists(ctx context.Context, name string) (bool, error) {
+	resp, err := get(ctx, fmt.Sprintf("%s/user/%s.json", baseURL, url.PathEscape(name)))
+	if err != nil {
+		return false, fmt.Errorf("error fetching user: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var user *struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return false, fmt.Errorf("error decoding user: %w", err)
+	}
+	return user != nil && user.ID != "", nil
+}
+
+// fetchCommentThread loads a story's whole comment tree with a single
+// request to the Algolia items endpoint.
+func (p *Provider) fetchCommentThread(ctx context.Context, id int) ([]feed.Comment, error) {
+	resp, err := get(ctx, fmt.Sprintf("%s/items/%d", algoliaURL, id))
+	if err != nil {
+		return nil, fmt.Errorf("error fetching thread: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("algolia returned status %d", resp.StatusCode)
+	}
+
+	var story algoliaItem
+	if err := json.NewDecoder(resp.Body).Decode(&story); err != nil {
+		return nil, fmt.Errorf("error decoding thread: %w", err)
+	}
+
+	return convertThread(story.Children, 0), nil
+}
+
+func convertThread(items []algoliaItem, depth int) []feed.Comment {
+	comments := make([]feed.Comment, 0, len(items))
+	for _, item := range items {
+		if item.Type != "comment" || item.Author == nil || item.Text == nil {
+			continue
+		}
+
+		score := 0
+		if item.Points != nil {
+			score = *item.Points
+		}
+
+		comments = append(comments, feed.Comment{
+			ID:        strconv.Itoa(item.ID),
+			Author:    *item.Author,
+			Body:      *item.Text,
+			Score:     score,
+			CreatedAt: time.Unix(item.CreatedAtI, 0),
+			Depth:     depth,
+			Replies:   convertThread(item.Children, depth+1),
+		})
+	}
+	return comments
+}
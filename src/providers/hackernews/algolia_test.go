@@ -0,0 +1,68 @@
+package hackernews
+
+import "testing"
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		identifier string
+		want       query
+		str        string
+		ok         bool
+	}{
+		{"top", query{kind: "top"}, "top", true},
+		{"top;points>=100", query{kind: "top", minPoints: 100}, "top;points>=100", true},
+		{"search: rust ", query{kind: "search", value: "rust"}, "search:rust", true},
+		{"search:a;b", query{kind: "search", value: "a;b"}, "search:a;b", true},
+		{"search:a;b;points>=5", query{kind: "search", value: "a;b", minPoints: 5}, "search:a;b;points>=5", true},
+		{"search:lang:go", query{kind: "search", value: "lang:go"}, "search:lang:go", true},
+		{"user:pg", query{kind: "user", value: "pg"}, "user:pg", true},
+		{"front:2024-01-02", query{kind: "front", value: "2024-01-02"}, "front:2024-01-02", true},
+		{"front:yesterday", query{}, "", false},
+		{"search:", query{}, "", false},
+		{"top;points>=many", query{}, "", false},
+		{"top;points>=-1", query{}, "", false},
+		{"nope:x", query{}, "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := parseQuery(tt.identifier)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseQuery(%q) error = %v, want ok %v", tt.identifier, err, tt.ok)
+			continue
+		}
+		if !tt.ok {
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseQuery(%q) = %+v, want %+v", tt.identifier, got, tt.want)
+		}
+		if s := got.String(); s != tt.str {
+			t.Errorf("parseQuery(%q).String() = %q, want %q", tt.identifier, s, tt.str)
+		}
+	}
+}
+
+func TestSourceName(t *testing.T) {
+	tests := []struct {
+		identifier string
+		want       string
+	}{
+		{"top", "HackerNews/top"},
+		{"top;points>=100", "HackerNews/top;points>=100"},
+		{"search:rust", "HackerNews/search/rust"},
+		{"search:rust;points>=50", "HackerNews/search/rust;points>=50"},
+		{"search:lang:go", "HackerNews/search/lang:go:"},
+		{"user:pg", "HackerNews/user/pg"},
+		{"front:2024-01-02", "HackerNews/front/2024-01-02"},
+	}
+
+	for _, tt := range tests {
+		q, err := parseQuery(tt.identifier)
+		if err != nil {
+			t.Fatalf("parseQuery(%q): %v", tt.identifier, err)
+		}
+		if got := q.sourceName(); got != tt.want {
+			t.Errorf("sourceName(%q) = %q, want %q", tt.identifier, got, tt.want)
+		}
+	}
+}
@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/snoofox/snoo/src/debug"
 	"github.com/snoofox/snoo/src/feed"
+	"github.com/snoofox/snoo/src/httpclient"
 )
 
 const (
@@ -17,15 +20,6 @@ const (
 	hnURL   = "https://news.ycombinator.com"
 )
 
-var httpClient = &http.Client{
-	Timeout: 10 * time.Second,
-	Transport: &http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 100,
-		IdleConnTimeout:     90 * time.Second,
-	},
-}
-
 type Provider struct{}
 
 func New() *Provider {
@@ -53,22 +47,39 @@ type hnItem struct {
 }
 
 func (p *Provider) FetchPosts(ctx context.Context, source feed.Source) ([]feed.Post, error) {
+	q, err := parseQuery(source.Identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.isAlgolia() {
+		items, err := p.fetchAlgolia(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+
+		posts := make([]feed.Post, 0, len(items))
+		for _, item := range items {
+			posts = append(posts, p.itemToPost(item, q.sourceName()))
+		}
+		return posts, nil
+	}
+
 	var storyIDs []int
-	var err error
 
-	switch source.Identifier {
+	switch q.kind {
 	case "top":
-		storyIDs, err = p.fetchStoryIDs("topstories")
+		storyIDs, err = p.fetchStoryIDs(ctx, "topstories")
 	case "new":
-		storyIDs, err = p.fetchStoryIDs("newstories")
+		storyIDs, err = p.fetchStoryIDs(ctx, "newstories")
 	case "best":
-		storyIDs, err = p.fetchStoryIDs("beststories")
+		storyIDs, err = p.fetchStoryIDs(ctx, "beststories")
 	case "ask":
-		storyIDs, err = p.fetchStoryIDs("askstories")
+		storyIDs, err = p.fetchStoryIDs(ctx, "askstories")
 	case "show":
-		storyIDs, err = p.fetchStoryIDs("showstories")
+		storyIDs, err = p.fetchStoryIDs(ctx, "showstories")
 	case "job":
-		storyIDs, err = p.fetchStoryIDs("jobstories")
+		storyIDs, err = p.fetchStoryIDs(ctx, "jobstories")
 	default:
 		return nil, fmt.Errorf("unknown HackerNews category: %s", source.Identifier)
 	}
@@ -82,7 +93,19 @@ func (p *Provider) FetchPosts(ctx context.Context, source feed.Source) ([]feed.P
 		storyIDs = storyIDs[:limit]
 	}
 
-	posts := p.fetchItemsConcurrently(storyIDs, source.Identifier)
+	posts := p.fetchItemsConcurrently(ctx, storyIDs, q.sourceName())
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if q.minPoints > 0 {
+		filtered := posts[:0]
+		for _, post := range posts {
+			if post.Score >= q.minPoints {
+				filtered = append(filtered, post)
+			}
+		}
+		posts = filtered
+	}
 	return posts, nil
 }
 
@@ -90,7 +113,24 @@ func (p *Provider) FetchComments(ctx context.Context, post feed.Post) ([]feed.Co
 	var storyID int
 	fmt.Sscanf(post.ID, "%d", &storyID)
 
-	item, err := p.fetchItem(storyID)
+	comments, err := p.fetchCommentThread(ctx, storyID)
+	if err == nil {
+		return comments, nil
+	}
+	debug.Log("HackerNews: bulk thread fetch for %d failed, walking items instead: %v", storyID, err)
+
+	if ctx.Err() != nil {
+		return nil, err
+	}
+
+	return p.walkComments(ctx, storyID)
+}
+
+// walkComments fetches comments item by item from the Firebase API. It is
+// the fallback when the bulk endpoint is unavailable, so it only goes one
+// level deep to keep the number of requests bounded.
+func (p *Provider) walkComments(ctx context.Context, storyID int) ([]feed.Comment, error) {
+	item, err := p.fetchItem(ctx, storyID)
 	if err != nil {
 		return nil, err
 	}
@@ -105,37 +145,128 @@ func (p *Provider) FetchComments(ctx context.Context, post feed.Post) ([]feed.Co
 		topKids = topKids[:maxTopComments]
 	}
 
-	comments := p.fetchCommentsConcurrently(topKids, 0)
+	comments := p.fetchCommentsConcurrently(ctx, topKids, 0)
 	return comments, nil
 }
 
 func (p *Provider) ValidateSource(ctx context.Context, identifier string) (*feed.SourceMetadata, error) {
-	validCategories := map[string]string{
-		"top":  "Top Stories",
-		"new":  "New Stories",
-		"best": "Best Stories",
-		"ask":  "Ask HN",
-		"show": "Show HN",
-		"job":  "Jobs",
+	q, err := parseQuery(identifier)
+	if err != nil {
+		return nil, err
 	}
 
-	displayName, ok := validCategories[identifier]
-	if !ok {
-		return nil, fmt.Errorf("invalid category. Valid options: top, new, best, ask, show, job")
+	var displayName, description string
+	switch q.kind {
+	case "search":
+		displayName = fmt.Sprintf("Search %q", q.value)
+		description = fmt.Sprintf("HackerNews stories matching %q", q.value)
+	case "user":
+		exists, err := p.userExists(ctx, q.value)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("HackerNews user not found: %s", q.value)
+		}
+		displayName = "~" + q.value
+		description = fmt.Sprintf("HackerNews stories submitted by %s", q.value)
+	case "front":
+		day, _ := time.Parse("2006-01-02", q.value)
+		if day.After(time.Now()) {
+			return nil, fmt.Errorf("front page date is in the future")
+		}
+		displayName = "Front page " + q.value
+		description = fmt.Sprintf("HackerNews front page for %s", q.value)
+	default:
+		validCategories := map[string]string{
+			"top":  "Top Stories",
+			"new":  "New Stories",
+			"best": "Best Stories",
+			"ask":  "Ask HN",
+			"show": "Show HN",
+			"job":  "Jobs",
+		}
+
+		var ok bool
+		displayName, ok = validCategories[q.kind]
+		if !ok {
+			return nil, fmt.Errorf("invalid category. Valid options: top, new, best, ask, show, job, search:<query>, user:<name>, front:<YYYY-MM-DD>")
+		}
+		description = fmt.Sprintf("HackerNews %s feed", displayName)
+	}
+
+	if q.minPoints > 0 {
+		displayName += fmt.Sprintf(" (%d+ points)", q.minPoints)
 	}
 
 	return &feed.SourceMetadata{
-		Name:        identifier,
+		Name:        q.String(),
 		DisplayName: fmt.Sprintf("HackerNews - %s", displayName),
-		Description: fmt.Sprintf("HackerNews %s feed", displayName),
+		Description: description,
 		IconURL:     "https://news.ycombinator.com/favicon.ico",
 	}, nil
 }
 
-func (p *Provider) fetchStoryIDs(endpoint string) ([]int, error) {
+// MatchURL maps news.ycombinator.com and hn.algolia.com pages (and the
+// hn/<category> shorthand) onto the matching identifier.
+func (p *Provider) MatchURL(input string) (string, bool) {
+	if category, ok := strings.CutPrefix(input, "hn/"); ok {
+		return category, category != ""
+	}
+
+	u, ok := feed.ParseURL(input)
+	if !ok {
+		return "", false
+	}
+
+	if u.Host == "hn.algolia.com" {
+		if q := u.Query().Get("query"); q != "" {
+			return "search:" + q, true
+		}
+		return "", false
+	}
+	if u.Host != "news.ycombinator.com" {
+		return "", false
+	}
+
+	switch strings.Trim(u.Path, "/") {
+	case "user", "submitted":
+		if id := u.Query().Get("id"); id != "" {
+			return "user:" + id, true
+		}
+	case "front":
+		if day := u.Query().Get("day"); day != "" {
+			return "front:" + day, true
+		}
+	}
+
+	pages := map[string]string{
+		"":       "top",
+		"news":   "top",
+		"newest": "new",
+		"best":   "best",
+		"ask":    "ask",
+		"show":   "show",
+		"jobs":   "job",
+	}
+	category, ok := pages[strings.Trim(u.Path, "/")]
+	return category, ok
+}
+
+// get issues a GET request through the shared client that is cancelled
+// with ctx.
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	return httpclient.For("hackernews").Do(req)
+}
+
+func (p *Provider) fetchStoryIDs(ctx context.Context, endpoint string) ([]int, error) {
 	url := fmt.Sprintf("%s/%s.json", baseURL, endpoint)
 
-	resp, err := httpClient.Get(url)
+	resp, err := get(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching story IDs: %w", err)
 	}
@@ -149,10 +280,10 @@ func (p *Provider) fetchStoryIDs(endpoint string) ([]int, error) {
 	return ids, nil
 }
 
-func (p *Provider) fetchItem(id int) (*hnItem, error) {
+func (p *Provider) fetchItem(ctx context.Context, id int) (*hnItem, error) {
 	url := fmt.Sprintf("%s/item/%d.json", baseURL, id)
 
-	resp, err := httpClient.Get(url)
+	resp, err := get(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching item: %w", err)
 	}
@@ -171,7 +302,7 @@ func (p *Provider) fetchItem(id int) (*hnItem, error) {
 	return &item, nil
 }
 
-func (p *Provider) fetchItemsConcurrently(ids []int, category string) []feed.Post {
+func (p *Provider) fetchItemsConcurrently(ctx context.Context, ids []int, sourceName string) []feed.Post {
 	type result struct {
 		post feed.Post
 		err  error
@@ -186,16 +317,20 @@ func (p *Provider) fetchItemsConcurrently(ids []int, category string) []feed.Pos
 		wg.Add(1)
 		go func(itemID int) {
 			defer wg.Done()
-			semaphore <- struct{}{}        // Acquire
+			select {
+			case semaphore <- struct{}{}: // Acquire
+			case <-ctx.Done():
+				return
+			}
 			defer func() { <-semaphore }() // Release
 
-			item, err := p.fetchItem(itemID)
+			item, err := p.fetchItem(ctx, itemID)
 			if err != nil || item == nil || item.Deleted || item.Dead {
 				results <- result{err: err}
 				return
 			}
 
-			post := p.itemToPost(item, category)
+			post := p.itemToPost(item, sourceName)
 			results <- result{post: post}
 		}(id)
 	}
@@ -215,7 +350,7 @@ func (p *Provider) fetchItemsConcurrently(ids []int, category string) []feed.Pos
 	return posts
 }
 
-func (p *Provider) fetchCommentsConcurrently(ids []int, depth int) []feed.Comment {
+func (p *Provider) fetchCommentsConcurrently(ctx context.Context, ids []int, depth int) []feed.Comment {
 	type result struct {
 		comment *feed.Comment
 		index   int
@@ -230,10 +365,14 @@ func (p *Provider) fetchCommentsConcurrently(ids []int, depth int) []feed.Commen
 		wg.Add(1)
 		go func(itemID, idx int) {
 			defer wg.Done()
-			semaphore <- struct{}{}        // Acquire
+			select {
+			case semaphore <- struct{}{}: // Acquire
+			case <-ctx.Done():
+				return
+			}
 			defer func() { <-semaphore }() // Release
 
-			comment, err := p.fetchCommentTree(itemID, depth)
+			comment, err := p.fetchCommentTree(ctx, itemID, depth)
 			if err == nil && comment != nil {
 				results <- result{comment: comment, index: idx}
 			}
@@ -260,8 +399,8 @@ func (p *Provider) fetchCommentsConcurrently(ids []int, depth int) []feed.Commen
 	return comments
 }
 
-func (p *Provider) fetchCommentTree(id int, depth int) (*feed.Comment, error) {
-	item, err := p.fetchItem(id)
+func (p *Provider) fetchCommentTree(ctx context.Context, id int, depth int) (*feed.Comment, error) {
+	item, err := p.fetchItem(ctx, id)
 	if err != nil || item == nil || item.Deleted || item.Dead {
 		return nil, err
 	}
@@ -286,13 +425,13 @@ func (p *Provider) fetchCommentTree(id int, depth int) (*feed.Comment, error) {
 		if len(kids) > maxReplies {
 			kids = kids[:maxReplies]
 		}
-		comment.Replies = p.fetchCommentsConcurrently(kids, depth+1)
+		comment.Replies = p.fetchCommentsConcurrently(ctx, kids, depth+1)
 	}
 
 	return comment, nil
 }
 
-func (p *Provider) itemToPost(item *hnItem, category string) feed.Post {
+func (p *Provider) itemToPost(item *hnItem, sourceName string) feed.Post {
 	url := item.URL
 	if url == "" {
 		url = fmt.Sprintf("%s/item?id=%d", hnURL, item.ID)
@@ -302,7 +441,7 @@ func (p *Provider) itemToPost(item *hnItem, category string) feed.Post {
 		ID:          fmt.Sprintf("%d", item.ID),
 		Title:       item.Title,
 		Author:      item.By,
-		SourceName:  fmt.Sprintf("HackerNews/%s", category),
+		SourceName:  sourceName,
 		SourceType:  "hackernews",
 		Permalink:   fmt.Sprintf("/item?id=%d", item.ID),
 		URL:         url,
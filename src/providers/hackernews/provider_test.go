@@ -0,0 +1,36 @@
+package hackernews
+
+import "testing"
+
+func TestMatchURL(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"hn/top", "top", true},
+		{"hn/search:rust", "search:rust", true},
+		{"hn/", "", false},
+		{"https://news.ycombinator.com/", "top", true},
+		{"news.ycombinator.com/news", "top", true},
+		{"https://news.ycombinator.com/newest", "new", true},
+		{"https://news.ycombinator.com/jobs", "job", true},
+		{"https://news.ycombinator.com/user?id=pg", "user:pg", true},
+		{"https://news.ycombinator.com/submitted?id=pg", "user:pg", true},
+		{"https://news.ycombinator.com/user", "", false},
+		{"https://news.ycombinator.com/front?day=2024-01-02", "front:2024-01-02", true},
+		{"https://news.ycombinator.com/item?id=1", "", false},
+		{"https://hn.algolia.com/?query=go%20generics&sort=byDate", "search:go generics", true},
+		{"https://hn.algolia.com/", "", false},
+		{"https://example.com/newest", "", false},
+		{"top", "", false},
+	}
+
+	p := New()
+	for _, tt := range tests {
+		got, ok := p.MatchURL(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("MatchURL(%q) = %q, %v; want %q, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/snoofox/snoo/src/feed"
+	"github.com/snoofox/snoo/src/httpclient"
 )
 
 const baseURL = "https://lobste.rs"
@@ -24,21 +26,52 @@ func (p *Provider) Type() string {
 }
 
 func (p *Provider) FetchPosts(ctx context.Context, source feed.Source) ([]feed.Post, error) {
-	var url string
-	if source.Identifier == "active" || source.Identifier == "recent" {
-		url = fmt.Sprintf("%s/%s.json", baseURL, source.Identifier)
-	} else {
-		return nil, fmt.Errorf("invalid lobsters category: %s (use 'active' or 'recent')", source.Identifier)
+	url, err := feedURL(source.Identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	stories, err := fetchStories(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	posts := make([]feed.Post, 0, len(stories))
+	for _, story := range stories {
+		post := parsePost(story, source.Identifier)
+		posts = append(posts, post)
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	return posts, nil
+}
+
+// feedURL maps an identifier onto its lobste.rs JSON endpoint:
+//
+//	active, recent, newest  front page listings
+//	t/<tag>[,<tag>...]      stories with any of the tags
+//	domain/<domain>         stories linking to a domain
+//	~<user>                 stories submitted by a user
+func feedURL(identifier string) (string, error) {
+	switch {
+	case identifier == "active" || identifier == "recent" || identifier == "newest":
+		return fmt.Sprintf("%s/%s.json", baseURL, identifier), nil
+	case strings.HasPrefix(identifier, "t/") && len(identifier) > 2:
+		return fmt.Sprintf("%s/t/%s.json", baseURL, identifier[2:]), nil
+	case strings.HasPrefix(identifier, "domain/") && len(identifier) > 7:
+		return fmt.Sprintf("%s/domains/%s.json", baseURL, identifier[7:]), nil
+	case strings.HasPrefix(identifier, "~") && len(identifier) > 1:
+		return fmt.Sprintf("%s/newest/%s.json", baseURL, identifier[1:]), nil
+	}
+	return "", fmt.Errorf("invalid lobsters feed: %s (use active, recent, newest, t/<tag>, domain/<domain> or ~<user>)", identifier)
+}
+
+func fetchStories(ctx context.Context, url string) ([]LobstersStory, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
-	req.Header.Set("User-Agent", "snoo:v1.0.0")
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := httpclient.For("lobsters").Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching lobsters: %w", err)
 	}
@@ -58,26 +91,18 @@ func (p *Provider) FetchPosts(ctx context.Context, source feed.Source) ([]feed.P
 		return nil, fmt.Errorf("error unmarshalling response: %w", err)
 	}
 
-	posts := make([]feed.Post, 0, len(stories))
-	for _, story := range stories {
-		post := parsePost(story, source.Identifier)
-		posts = append(posts, post)
-	}
-
-	return posts, nil
+	return stories, nil
 }
 
 func (p *Provider) FetchComments(ctx context.Context, post feed.Post) ([]feed.Comment, error) {
 	url := fmt.Sprintf("%s/s/%s.json", baseURL, post.ID)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
-	req.Header.Set("User-Agent", "snoo:v1.0.0")
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := httpclient.For("lobsters").Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching comments: %w", err)
 	}
@@ -107,12 +132,39 @@ func (p *Provider) FetchComments(ctx context.Context, post feed.Post) ([]feed.Co
 }
 
 func (p *Provider) ValidateSource(ctx context.Context, identifier string) (*feed.SourceMetadata, error) {
-	if identifier != "active" && identifier != "recent" {
-		return nil, fmt.Errorf("invalid lobsters category: %s (use 'active' or 'recent')", identifier)
+	identifier = strings.TrimSpace(identifier)
+	if strings.HasPrefix(identifier, "t/") {
+		identifier = strings.ToLower(identifier)
+	}
+
+	url, err := feedURL(identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var displayName, description string
+	switch {
+	case strings.HasPrefix(identifier, "t/"):
+		tags := strings.Split(identifier[2:], ",")
+		displayName = "Lobsters - #" + strings.Join(tags, " #")
+		description = fmt.Sprintf("Lobste.rs stories tagged %s", strings.Join(tags, ", "))
+	case strings.HasPrefix(identifier, "domain/"):
+		displayName = "Lobsters - " + identifier[7:]
+		description = fmt.Sprintf("Lobste.rs stories from %s", identifier[7:])
+	case strings.HasPrefix(identifier, "~"):
+		displayName = "Lobsters - " + identifier
+		description = fmt.Sprintf("Lobste.rs stories submitted by %s", identifier[1:])
+	default:
+		displayName = fmt.Sprintf("Lobsters - %s", identifier)
+		description = fmt.Sprintf("Lobste.rs %s stories", identifier)
 	}
 
-	displayName := fmt.Sprintf("Lobsters - %s", identifier)
-	description := fmt.Sprintf("Lobste.rs %s stories", identifier)
+	// Tags, domains and users can be mistyped; make sure lobste.rs knows them.
+	if identifier != "active" && identifier != "recent" && identifier != "newest" {
+		if _, err := fetchStories(ctx, url); err != nil {
+			return nil, fmt.Errorf("lobsters feed %s not found: %w", identifier, err)
+		}
+	}
 
 	return &feed.SourceMetadata{
 		Name:        identifier,
@@ -122,6 +174,38 @@ func (p *Provider) ValidateSource(ctx context.Context, identifier string) (*feed
 	}, nil
 }
 
+// MatchURL maps lobste.rs pages (and the lobsters/<category> shorthand)
+// onto the matching category.
+func (p *Provider) MatchURL(input string) (string, bool) {
+	if category, ok := strings.CutPrefix(input, "lobsters/"); ok {
+		return category, category != ""
+	}
+
+	u, ok := feed.ParseURL(input)
+	if !ok || u.Host != "lobste.rs" {
+		return "", false
+	}
+
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".json")
+	parts := strings.Split(path, "/")
+	switch {
+	case path == "" || path == "active":
+		return "active", true
+	case path == "recent" || path == "newest":
+		return path, true
+	case parts[0] == "t" && len(parts) == 2:
+		return "t/" + parts[1], true
+	case (parts[0] == "domains" || parts[0] == "domain") && len(parts) == 2:
+		return "domain/" + parts[1], true
+	case parts[0] == "newest" && len(parts) == 2:
+		return "~" + parts[1], true
+	case strings.HasPrefix(parts[0], "~"):
+		return parts[0], true
+	}
+	// Other pages, such as /rss or a story, are left to the RSS provider.
+	return "", false
+}
+
 type LobstersStory struct {
 	ShortID       string            `json:"short_id"`
 	Title         string            `json:"title"`
@@ -164,6 +248,7 @@ func parsePost(story LobstersStory, category string) feed.Post {
 		Content:     story.Description,
 		Thumbnail:   "",
 		NSFW:        false,
+		Tags:        story.Tags,
 	}
 }
 
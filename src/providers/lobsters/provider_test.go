@@ -0,0 +1,35 @@
+package lobsters
+
+import "testing"
+
+func TestMatchURL(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"lobsters/t/go", "t/go", true},
+		{"lobsters/", "", false},
+		{"https://lobste.rs", "active", true},
+		{"https://lobste.rs/active", "active", true},
+		{"lobste.rs/recent", "recent", true},
+		{"https://lobste.rs/newest", "newest", true},
+		{"https://lobste.rs/newest/jcs", "~jcs", true},
+		{"https://lobste.rs/t/go", "t/go", true},
+		{"https://lobste.rs/t/go.json", "t/go", true},
+		{"https://lobste.rs/t/go/page/2", "", false},
+		{"https://lobste.rs/domains/github.com", "domain/github.com", true},
+		{"https://lobste.rs/~jcs", "~jcs", true},
+		{"https://lobste.rs/rss", "", false},
+		{"https://lobste.rs/s/abc123/title", "", false},
+		{"https://example.com/t/go", "", false},
+	}
+
+	p := New()
+	for _, tt := range tests {
+		got, ok := p.MatchURL(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("MatchURL(%q) = %q, %v; want %q, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
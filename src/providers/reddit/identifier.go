@@ -0,0 +1,238 @@
+package reddit
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// listing is a parsed reddit source identifier. Supported forms:
+//
+//	golang[:sort]                    a subreddit
+//	golang+rust+zig[:sort]           several subreddits combined
+//	u/name[:sort]                    a user's submissions
+//	user/m/multi[:sort]              a user's multireddit
+//	search:query[@subreddit][:sort]  a saved search, optionally in one subreddit
+//
+// sort may carry a time window for top and controversial, e.g. top/week.
+// A search's subreddit is only what follows its last @, and only if that
+// is a subreddit name, so queries may contain @ of their own; a trailing @
+// marks a search of all of reddit.
+type listing struct {
+	kind   string // "subreddit", "user", "multi" or "search"
+	name   string // subreddit(s), user name or multireddit owner
+	multi  string
+	query  string
+	sort   string
+	window string
+}
+
+var (
+	validSorts = map[string]map[string]bool{
+		"subreddit": {"hot": true, "new": true, "rising": true, "top": true, "best": true, "controversial": true},
+		"multi":     {"hot": true, "new": true, "rising": true, "top": true, "controversial": true},
+		"user":      {"hot": true, "new": true, "top": true, "controversial": true},
+		"search":    {"relevance": true, "hot": true, "top": true, "new": true, "comments": true},
+	}
+	defaultSorts = map[string]string{
+		"subreddit": "best",
+		"multi":     "hot",
+		"user":      "new",
+		"search":    "new",
+	}
+	validWindows = map[string]bool{
+		"hour": true, "day": true, "week": true, "month": true, "year": true, "all": true,
+	}
+)
+
+func parseIdentifier(identifier string) listing {
+	identifier = strings.TrimSpace(identifier)
+
+	var l listing
+	rest := identifier
+	switch {
+	case strings.HasPrefix(identifier, "search:"):
+		l.kind = "search"
+		rest = identifier[len("search:"):]
+	case strings.Contains(identifier, "/m/"):
+		l.kind = "multi"
+	case strings.HasPrefix(identifier, "u/"):
+		l.kind = "user"
+		rest = identifier[len("u/"):]
+	default:
+		l.kind = "subreddit"
+	}
+
+	// The sort is whatever follows the last colon, as long as it looks like
+	// one; search queries may contain colons of their own.
+	l.sort = defaultSorts[l.kind]
+	if i := strings.LastIndex(rest, ":"); i != -1 {
+		candidate := rest[i+1:]
+		sortName, _, _ := strings.Cut(candidate, "/")
+		if l.kind != "search" || validSorts[l.kind][sortName] {
+			rest = rest[:i]
+			l.sort, l.window, _ = strings.Cut(candidate, "/")
+		}
+	}
+
+	switch l.kind {
+	case "search":
+		l.query = rest
+		if i := strings.LastIndex(rest, "@"); i != -1 {
+			if name := strings.TrimPrefix(rest[i+1:], "r/"); isSubredditName(name) {
+				l.query, l.name = rest[:i], name
+			}
+		}
+	case "multi":
+		l.name, l.multi, _ = strings.Cut(rest, "/m/")
+		l.name = strings.TrimPrefix(strings.TrimPrefix(l.name, "u/"), "user/")
+	default:
+		l.name = strings.TrimPrefix(rest, "r/")
+	}
+
+	return l
+}
+
+// isSubredditName reports whether s could name a subreddit, or several
+// joined with +. The empty name stands for all of reddit.
+func isSubredditName(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '+') {
+			return false
+		}
+	}
+	return true
+}
+
+func (l listing) validate() error {
+	if !validSorts[l.kind][l.sort] {
+		sorts := make([]string, 0, len(validSorts[l.kind]))
+		for s := range validSorts[l.kind] {
+			sorts = append(sorts, s)
+		}
+		sort.Strings(sorts)
+		return fmt.Errorf("invalid sort type for %s: %s (use %s)", l.kind, l.sort, strings.Join(sorts, ", "))
+	}
+
+	if l.window != "" {
+		windowed := l.sort == "top" || l.sort == "controversial"
+		if l.kind == "search" {
+			windowed = l.sort == "top" || l.sort == "relevance" || l.sort == "comments"
+		}
+		if !windowed {
+			return fmt.Errorf("time window does not apply to %s", l.sort)
+		}
+		if !validWindows[l.window] {
+			return fmt.Errorf("invalid time window: %s (use hour, day, week, month, year, or all)", l.window)
+		}
+	}
+
+	switch l.kind {
+	case "search":
+		if strings.TrimSpace(l.query) == "" {
+			return fmt.Errorf("search query is empty")
+		}
+	case "multi":
+		if l.name == "" || l.multi == "" {
+			return fmt.Errorf("multireddit must look like user/m/name")
+		}
+	default:
+		if l.name == "" {
+			return fmt.Errorf("%s name is empty", l.kind)
+		}
+	}
+
+	return nil
+}
+
+// sortSpec is the sort with its time window, e.g. "top/week".
+func (l listing) sortSpec() string {
+	if l.window != "" {
+		return l.sort + "/" + l.window
+	}
+	return l.sort
+}
+
+// String is the normalized identifier stored on the source.
+func (l listing) String() string {
+	switch l.kind {
+	case "search":
+		s := "search:" + l.query
+		if l.name != "" || strings.Contains(l.query, "@") {
+			s += "@" + l.name
+		}
+		return s + ":" + l.sortSpec()
+	case "user":
+		return fmt.Sprintf("u/%s:%s", l.name, l.sortSpec())
+	case "multi":
+		return fmt.Sprintf("%s/m/%s:%s", l.name, l.multi, l.sortSpec())
+	}
+	return fmt.Sprintf("%s:%s", l.name, l.sortSpec())
+}
+
+// sourceName labels posts in the feed; everything after the last colon is
+// hidden by the list view.
+func (l listing) sourceName() string {
+	switch l.kind {
+	case "search":
+		s := "search/" + l.query
+		if l.name != "" {
+			s += "@" + l.name
+		}
+		return s + ":" + l.sortSpec()
+	case "user":
+		return fmt.Sprintf("u/%s:%s", l.name, l.sortSpec())
+	case "multi":
+		return fmt.Sprintf("m/%s:%s", l.multi, l.sortSpec())
+	}
+	return fmt.Sprintf("r/%s:%s", l.name, l.sortSpec())
+}
+
+func (l listing) displayName() string {
+	switch l.kind {
+	case "search":
+		if l.name != "" {
+			return fmt.Sprintf("search \"%s\" in r/%s (%s)", l.query, l.name, l.sortSpec())
+		}
+		return fmt.Sprintf("search \"%s\" (%s)", l.query, l.sortSpec())
+	case "user":
+		return fmt.Sprintf("u/%s (%s)", l.name, l.sortSpec())
+	case "multi":
+		return fmt.Sprintf("m/%s by u/%s (%s)", l.multi, l.name, l.sortSpec())
+	}
+	return fmt.Sprintf("r/%s (%s)", l.name, l.sortSpec())
+}
+
+// url is the JSON listing endpoint for this identifier.
+func (l listing) url() string {
+	params := url.Values{}
+	if l.window != "" {
+		params.Set("t", l.window)
+	}
+
+	var path string
+	switch l.kind {
+	case "search":
+		params.Set("q", l.query)
+		params.Set("sort", l.sort)
+		if l.name != "" {
+			params.Set("restrict_sr", "1")
+			path = fmt.Sprintf("/r/%s/search.json", l.name)
+		} else {
+			path = "/search.json"
+		}
+	case "user":
+		params.Set("sort", l.sort)
+		path = fmt.Sprintf("/user/%s/submitted.json", l.name)
+	case "multi":
+		path = fmt.Sprintf("/user/%s/m/%s/%s.json", l.name, l.multi, l.sort)
+	default:
+		path = fmt.Sprintf("/r/%s/%s.json", l.name, l.sort)
+	}
+
+	if len(params) == 0 {
+		return baseURL + path
+	}
+	return baseURL + path + "?" + params.Encode()
+}
@@ -0,0 +1,56 @@
+package reddit
+
+import "testing"
+
+func TestParseIdentifier(t *testing.T) {
+	tests := []struct {
+		identifier string
+		want       string // normalized identifier
+		source     string // source name
+		ok         bool
+	}{
+		{"golang", "golang:best", "r/golang:best", true},
+		{" r/golang:top/week ", "golang:top/week", "r/golang:top/week", true},
+		{"golang+rust:new", "golang+rust:new", "r/golang+rust:new", true},
+		{"golang:top/fortnight", "", "", false},
+		{"golang:new/week", "", "", false},
+		{"golang:sideways", "", "", false},
+		{":hot", "", "", false},
+		{"u/spez", "u/spez:new", "u/spez:new", true},
+		{"u/spez:best", "", "", false},
+		{"spez/m/tech", "spez/m/tech:hot", "m/tech:hot", true},
+		{"user/spez/m/tech:top/month", "spez/m/tech:top/month", "m/tech:top/month", true},
+		{"/m/tech", "", "", false},
+		{"search:go generics", "search:go generics:new", "search/go generics:new", true},
+		{"search:rust@programming:top/week", "search:rust@programming:top/week", "search/rust@programming:top/week", true},
+		{"search:rust@r/programming", "search:rust@programming:new", "search/rust@programming:new", true},
+		{"search:from:me@example.com", "search:from:me@example.com@:new", "search/from:me@example.com:new", true},
+		{"search:site:github.com", "search:site:github.com:new", "search/site:github.com:new", true},
+		{"search:go:relevance/year", "search:go:relevance/year", "search/go:relevance/year", true},
+		{"search:go:new/year", "", "", false},
+		{"search::top", "", "", false},
+	}
+
+	for _, tt := range tests {
+		l := parseIdentifier(tt.identifier)
+		err := l.validate()
+		if (err == nil) != tt.ok {
+			t.Errorf("parseIdentifier(%q).validate() = %v, want ok %v", tt.identifier, err, tt.ok)
+			continue
+		}
+		if !tt.ok {
+			continue
+		}
+		if got := l.String(); got != tt.want {
+			t.Errorf("parseIdentifier(%q).String() = %q, want %q", tt.identifier, got, tt.want)
+		}
+		if got := l.sourceName(); got != tt.source {
+			t.Errorf("parseIdentifier(%q).sourceName() = %q, want %q", tt.identifier, got, tt.source)
+		}
+
+		// The normalized form parses back to the same listing.
+		if again := parseIdentifier(l.String()); again != l {
+			t.Errorf("parseIdentifier(%q) = %+v, which round-trips to %+v", tt.identifier, l, again)
+		}
+	}
+}
@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/snoofox/snoo/src/feed"
+	"github.com/snoofox/snoo/src/httpclient"
 )
 
 const baseURL = "https://www.reddit.com"
@@ -24,17 +27,14 @@ func (p *Provider) Type() string {
 }
 
 func (p *Provider) FetchPosts(ctx context.Context, source feed.Source) ([]feed.Post, error) {
-	subreddit, sort := parseIdentifier(source.Identifier)
-	url := fmt.Sprintf("%s/r/%s/%s.json", baseURL, subreddit, sort)
+	url := parseIdentifier(source.Identifier).url()
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
-	req.Header.Set("User-Agent", "snoo:v1.0.0")
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := httpclient.For("reddit").Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching subreddit: %w", err)
 	}
@@ -84,14 +84,12 @@ func (p *Provider) FetchPosts(ctx context.Context, source feed.Source) ([]feed.P
 func (p *Provider) FetchComments(ctx context.Context, post feed.Post) ([]feed.Comment, error) {
 	url := fmt.Sprintf("%s%s.json", baseURL, post.Permalink)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
-	req.Header.Set("User-Agent", "snoo:v1.0.0")
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := httpclient.For("reddit").Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching comments: %w", err)
 	}
@@ -153,32 +151,83 @@ func (p *Provider) FetchComments(ctx context.Context, post feed.Post) ([]feed.Co
 }
 
 func (p *Provider) ValidateSource(ctx context.Context, identifier string) (*feed.SourceMetadata, error) {
-	subreddit, sort := parseIdentifier(identifier)
+	l := parseIdentifier(identifier)
+	if err := l.validate(); err != nil {
+		return nil, err
+	}
 
-	validSorts := map[string]bool{
-		"hot": true, "new": true, "rising": true, "top": true, "best": true,
+	metadata := &feed.SourceMetadata{
+		Name:        l.String(),
+		DisplayName: l.displayName(),
 	}
-	if !validSorts[sort] {
-		return nil, fmt.Errorf("invalid sort type: %s (use hot, new, rising, top, or best)", sort)
+
+	switch l.kind {
+	case "subreddit":
+		for _, subreddit := range strings.Split(l.name, "+") {
+			data, err := fetchAbout(ctx, fmt.Sprintf("%s/r/%s/about.json", baseURL, subreddit))
+			if err != nil {
+				return nil, fmt.Errorf("r/%s: %w", subreddit, err)
+			}
+
+			// A single subreddit keeps its own name, description and icon.
+			if !strings.Contains(l.name, "+") {
+				displayName, _ := data["display_name"].(string)
+				desc, _ := data["public_description"].(string)
+				iconURL, _ := data["icon_img"].(string)
+
+				metadata.DisplayName = fmt.Sprintf("r/%s (%s)", displayName, l.sortSpec())
+				metadata.Description = desc
+				metadata.IconURL = iconURL
+			}
+		}
+		if strings.Contains(l.name, "+") {
+			metadata.Description = "Combined feed of r/" + strings.ReplaceAll(l.name, "+", ", r/")
+		}
+	case "user":
+		data, err := fetchAbout(ctx, fmt.Sprintf("%s/user/%s/about.json", baseURL, l.name))
+		if err != nil {
+			return nil, fmt.Errorf("u/%s: %w", l.name, err)
+		}
+		iconURL, _ := data["icon_img"].(string)
+		metadata.Description = fmt.Sprintf("Submissions by u/%s", l.name)
+		metadata.IconURL = iconURL
+	case "multi":
+		data, err := fetchAbout(ctx, fmt.Sprintf("%s/api/multi/user/%s/m/%s", baseURL, l.name, l.multi))
+		if err != nil {
+			return nil, fmt.Errorf("multireddit %s/m/%s: %w", l.name, l.multi, err)
+		}
+		desc, _ := data["description_md"].(string)
+		iconURL, _ := data["icon_url"].(string)
+		metadata.Description = desc
+		metadata.IconURL = iconURL
+	case "search":
+		if l.name != "" {
+			if _, err := fetchAbout(ctx, fmt.Sprintf("%s/r/%s/about.json", baseURL, l.name)); err != nil {
+				return nil, fmt.Errorf("r/%s: %w", l.name, err)
+			}
+		}
+		metadata.Description = fmt.Sprintf("Reddit search for \"%s\"", l.query)
 	}
 
-	url := fmt.Sprintf("%s/r/%s/about.json", baseURL, subreddit)
+	return metadata, nil
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// fetchAbout fetches a reddit "about" style endpoint and returns its data
+// object.
+func fetchAbout(ctx context.Context, url string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
-	req.Header.Set("User-Agent", "snoo:v1.0.0")
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := httpclient.For("reddit").Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching subreddit: %w", err)
+		return nil, fmt.Errorf("error fetching %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("subreddit not found or unavailable")
+		return nil, fmt.Errorf("not found or unavailable")
 	}
 
 	jsonBytes, err := io.ReadAll(resp.Body)
@@ -196,33 +245,79 @@ func (p *Provider) ValidateSource(ctx context.Context, identifier string) (*feed
 		return nil, fmt.Errorf("invalid response format")
 	}
 
-	displayName, _ := data["display_name"].(string)
-	desc, _ := data["public_description"].(string)
-	iconURL, _ := data["icon_img"].(string)
+	return data, nil
+}
+
+// MatchURL recognizes reddit.com subreddit, user and multireddit URLs and
+// the r/<name> and u/<name> shorthands, returning a reddit identifier. A
+// sort in the path, and the t parameter's time window for top and
+// controversial, carry over, e.g. r/golang/top?t=week is golang:top/week.
+func (p *Provider) MatchURL(input string) (string, bool) {
+	if strings.HasPrefix(input, "r/") || strings.HasPrefix(input, "/r/") {
+		// A sort given the identifier's way, as in r/golang:top, is kept.
+		if name := strings.TrimPrefix(strings.TrimPrefix(input, "/"), "r/"); strings.Contains(name, ":") {
+			return name, true
+		}
+		u, err := url.Parse("/" + strings.TrimPrefix(input, "/"))
+		if err != nil {
+			return "", false
+		}
+		return matchPath(u)
+	}
+	if strings.HasPrefix(input, "u/") && len(input) > 2 {
+		return input, true
+	}
 
-	normalizedIdentifier := fmt.Sprintf("%s:%s", subreddit, sort)
+	u, ok := feed.ParseURL(input)
+	if !ok {
+		return "", false
+	}
 
-	return &feed.SourceMetadata{
-		Name:        normalizedIdentifier,
-		DisplayName: fmt.Sprintf("r/%s (%s)", displayName, sort),
-		Description: desc,
-		IconURL:     iconURL,
-	}, nil
+	host := u.Host
+	for _, prefix := range []string{"old.", "new.", "np."} {
+		host = strings.TrimPrefix(host, prefix)
+	}
+	if host != "reddit.com" {
+		return "", false
+	}
+	return matchPath(u)
 }
 
-func parseIdentifier(identifier string) (subreddit, sort string) {
-	subreddit = identifier
-	sort = "best"
-
-	for i := 0; i < len(identifier); i++ {
-		if identifier[i] == ':' {
-			subreddit = identifier[:i]
-			sort = identifier[i+1:]
-			break
+// matchPath returns the identifier for a reddit URL's path and query.
+func matchPath(u *url.URL) (string, bool) {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) >= 2 && (parts[0] == "user" || parts[0] == "u") && parts[1] != "" {
+		if len(parts) >= 4 && parts[2] == "m" && parts[3] != "" {
+			id := fmt.Sprintf("%s/m/%s", parts[1], parts[3])
+			if len(parts) >= 5 {
+				id += pathSort("multi", parts[4], u.Query())
+			}
+			return id, true
 		}
+		return "u/" + parts[1], true
+	}
+	if len(parts) < 2 || parts[0] != "r" || parts[1] == "" {
+		return "", false
+	}
+
+	if len(parts) >= 3 {
+		return parts[1] + pathSort("subreddit", parts[2], u.Query()), true
 	}
+	return parts[1], true
+}
 
-	return subreddit, sort
+// pathSort returns the ":sort" suffix for a sort found in a URL path, with
+// the time window of its t parameter where the sort takes one, or "" if
+// sortName isn't a sort of kind.
+func pathSort(kind, sortName string, query url.Values) string {
+	if !validSorts[kind][sortName] {
+		return ""
+	}
+	suffix := ":" + sortName
+	if t := query.Get("t"); validWindows[t] && (sortName == "top" || sortName == "controversial") {
+		suffix += "/" + t
+	}
+	return suffix
 }
 
 func parsePost(data map[string]any, identifier string) feed.Post {
@@ -244,8 +339,7 @@ func parsePost(data map[string]any, identifier string) feed.Post {
 		content = selftext
 	}
 
-	subreddit, sort := parseIdentifier(identifier)
-	sourceName := fmt.Sprintf("r/%s:%s", subreddit, sort)
+	sourceName := parseIdentifier(identifier).sourceName()
 
 	return feed.Post{
 		ID:          id,
@@ -0,0 +1,64 @@
+package reddit
+
+import "testing"
+
+func TestMatchURL(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"r/golang", "golang", true},
+		{"/r/golang", "golang", true},
+		{"r/golang/", "golang", true},
+		{"r/golang:top/week", "golang:top/week", true},
+		{"r/golang/top", "golang:top", true},
+		{"/r/golang/new", "golang:new", true},
+		{"r/golang/top?t=week", "golang:top/week", true},
+		{"r/golang/hot?t=week", "golang:hot", true},
+		{"r/golang/top?t=fortnight", "golang:top", true},
+		{"r/golang/comments/abc/title", "golang", true},
+		{"r/", "", false},
+		{"u/spez", "u/spez", true},
+
+		{"https://www.reddit.com/r/golang", "golang", true},
+		{"https://old.reddit.com/r/golang/top/", "golang:top", true},
+		{"https://www.reddit.com/r/golang/top/?t=week", "golang:top/week", true},
+		{"reddit.com/r/golang/controversial?t=all", "golang:controversial/all", true},
+		{"https://www.reddit.com/r/golang/rising?t=week", "golang:rising", true},
+		{"https://www.reddit.com/user/spez", "u/spez", true},
+		{"https://www.reddit.com/u/spez/submitted", "u/spez", true},
+		{"https://www.reddit.com/user/spez/m/tech", "spez/m/tech", true},
+		{"https://www.reddit.com/user/spez/m/tech/top?t=month", "spez/m/tech:top/month", true},
+		{"https://www.reddit.com/", "", false},
+		{"https://example.com/r/golang", "", false},
+		{"golang", "", false},
+	}
+
+	p := New()
+	for _, tt := range tests {
+		got, ok := p.MatchURL(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("MatchURL(%q) = %q, %v; want %q, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMatchURLValidates(t *testing.T) {
+	// Whatever MatchURL accepts must be a valid identifier.
+	for _, input := range []string{
+		"r/golang/top",
+		"r/golang/top?t=week",
+		"https://www.reddit.com/r/golang/controversial?t=all",
+		"https://www.reddit.com/user/spez/m/tech/top?t=month",
+	} {
+		id, ok := New().MatchURL(input)
+		if !ok {
+			t.Errorf("MatchURL(%q) failed", input)
+			continue
+		}
+		if err := parseIdentifier(id).validate(); err != nil {
+			t.Errorf("MatchURL(%q) = %q, which is invalid: %v", input, id, err)
+		}
+	}
+}
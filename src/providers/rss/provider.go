@@ -3,11 +3,16 @@ package rss
 import (
 	"context"
 	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mmcdole/gofeed"
 	"github.com/snoofox/snoo/src/debug"
 	"github.com/snoofox/snoo/src/feed"
+	"github.com/snoofox/snoo/src/httpclient"
 )
 
 type Provider struct{}
@@ -16,15 +21,50 @@ func New() *Provider {
 	return &Provider{}
 }
 
+// newParser returns a feed parser that fetches through the shared client.
+func newParser() *gofeed.Parser {
+	fp := gofeed.NewParser()
+	fp.Client = httpclient.For("rss")
+	fp.UserAgent = httpclient.UserAgent()
+	return fp
+}
+
 func (p *Provider) Type() string {
 	return "rss"
 }
 
+// MatchesAnyURL lets the RSS provider claim any http(s) URL that no other
+// provider recognized.
+func (p *Provider) MatchesAnyURL() bool {
+	return true
+}
+
+// MatchURL accepts any feed URL. GitHub repository URLs are mapped to the
+// repository's releases Atom feed.
+func (p *Provider) MatchURL(input string) (string, bool) {
+	u, ok := feed.ParseURL(input)
+	if !ok {
+		return "", false
+	}
+
+	if u.Host == "github.com" {
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) >= 2 && !strings.HasSuffix(u.Path, ".atom") {
+			return fmt.Sprintf("https://github.com/%s/%s/releases.atom", parts[0], parts[1]), true
+		}
+	}
+
+	if !strings.Contains(input, "://") {
+		input = "https://" + input
+	}
+	return input, true
+}
+
 func (p *Provider) FetchPosts(ctx context.Context, source feed.Source) ([]feed.Post, error) {
 	debug.Log("RSS: Fetching from %s", source.Identifier)
 
-	fp := gofeed.NewParser()
-	rssFeed, err := fp.ParseURL(source.Identifier)
+	fp := newParser()
+	rssFeed, err := fp.ParseURLWithContext(source.Identifier, ctx)
 	if err != nil {
 		debug.Log("RSS: Error parsing feed: %v", err)
 		return nil, fmt.Errorf("error parsing feed: %w", err)
@@ -34,14 +74,7 @@ func (p *Provider) FetchPosts(ctx context.Context, source feed.Source) ([]feed.P
 
 	posts := make([]feed.Post, 0, len(rssFeed.Items))
 	for i, item := range rssFeed.Items {
-		author := "Unknown"
-		if item.Author != nil && item.Author.Name != "" {
-			author = item.Author.Name
-		} else if len(item.Authors) > 0 && item.Authors[0].Name != "" {
-			author = item.Authors[0].Name
-		} else if item.DublinCoreExt != nil && len(item.DublinCoreExt.Creator) > 0 {
-			author = item.DublinCoreExt.Creator[0]
-		}
+		author := itemAuthor(item)
 
 		pubDate := time.Now()
 		if item.PublishedParsed != nil {
@@ -59,25 +92,51 @@ func (p *Provider) FetchPosts(ctx context.Context, source feed.Source) ([]feed.P
 		if guid == "" {
 			guid = item.Link
 		}
+		if guid == "" && len(item.Enclosures) > 0 {
+			guid = item.Enclosures[0].URL
+		}
 
 		if i < 5 {
 			debug.Log("RSS Item %d: GUID=%s, Title=%s, Link=%s", i, guid, item.Title, item.Link)
 		}
 
+		enclosure := parseEnclosure(item)
+
+		// Podcast episodes often have no description and link only to the media.
+		if content == "" && item.ITunesExt != nil {
+			content = item.ITunesExt.Summary
+		}
+
+		link := item.Link
+		if link == "" && enclosure != nil {
+			link = enclosure.URL
+		}
+
+		commentsURL, numComments := parseCommentExtensions(item)
+
+		thumbnail := ""
+		if item.Image != nil {
+			thumbnail = item.Image.URL
+		} else if item.ITunesExt != nil {
+			thumbnail = item.ITunesExt.Image
+		}
+
 		posts = append(posts, feed.Post{
 			ID:          guid,
 			Title:       item.Title,
 			Author:      author,
 			SourceName:  fmt.Sprintf("rss/%s", source.Name),
 			SourceType:  "rss",
-			Permalink:   item.Link,
-			URL:         item.Link,
+			Permalink:   link,
+			URL:         link,
 			Score:       0,
-			NumComments: 0,
+			NumComments: numComments,
 			CreatedAt:   pubDate,
 			Content:     content,
-			Thumbnail:   "",
+			Thumbnail:   thumbnail,
 			NSFW:        false,
+			Enclosure:   enclosure,
+			CommentsURL: commentsURL,
 		})
 	}
 
@@ -85,13 +144,61 @@ func (p *Provider) FetchPosts(ctx context.Context, source feed.Source) ([]feed.P
 	return posts, nil
 }
 
+// FetchComments reads the post's wfw:commentRss feed, if it has one.
 func (p *Provider) FetchComments(ctx context.Context, post feed.Post) ([]feed.Comment, error) {
-	return []feed.Comment{}, nil
+	if post.CommentsURL == "" {
+		return []feed.Comment{}, nil
+	}
+
+	debug.Log("RSS: Fetching comments from %s", post.CommentsURL)
+
+	fp := newParser()
+	commentFeed, err := fp.ParseURLWithContext(post.CommentsURL, ctx)
+	if err != nil {
+		debug.Log("RSS: Error parsing comment feed: %v", err)
+		return nil, fmt.Errorf("error parsing comment feed: %w", err)
+	}
+
+	comments := make([]feed.Comment, 0, len(commentFeed.Items))
+	for _, item := range commentFeed.Items {
+		body := item.Content
+		if body == "" {
+			body = item.Description
+		}
+
+		createdAt := time.Time{}
+		if item.PublishedParsed != nil {
+			createdAt = *item.PublishedParsed
+		} else if item.UpdatedParsed != nil {
+			createdAt = *item.UpdatedParsed
+		}
+
+		id := item.GUID
+		if id == "" {
+			id = item.Link
+		}
+
+		comments = append(comments, feed.Comment{
+			ID:        id,
+			Author:    itemAuthor(item),
+			Body:      stripHTML(body),
+			CreatedAt: createdAt,
+			Depth:     0,
+			Replies:   []feed.Comment{},
+		})
+	}
+
+	// Comment feeds list newest first; read them as a conversation.
+	for i, j := 0, len(comments)-1; i < j; i, j = i+1, j-1 {
+		comments[i], comments[j] = comments[j], comments[i]
+	}
+
+	return comments, nil
 }
 
 func (p *Provider) ValidateSource(ctx context.Context, identifier string) (*feed.SourceMetadata, error) {
-	fp := gofeed.NewParser()
-	rssFeed, err := fp.ParseURL(identifier)
+	fp := newParser()
+	rssFeed, err := fp.ParseURLWithContext(identifier, ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing feed: %w", err)
 	}
@@ -113,3 +220,87 @@ func (p *Provider) ValidateSource(ctx context.Context, identifier string) (*feed
 		IconURL:     iconURL,
 	}, nil
 }
+
+// parseEnclosure returns the item's first media enclosure, using the
+// iTunes extension for the duration when present.
+func parseEnclosure(item *gofeed.Item) *feed.Enclosure {
+	if len(item.Enclosures) == 0 || item.Enclosures[0].URL == "" {
+		return nil
+	}
+
+	e := item.Enclosures[0]
+	length, _ := strconv.ParseInt(strings.TrimSpace(e.Length), 10, 64)
+
+	enclosure := &feed.Enclosure{
+		URL:    e.URL,
+		Type:   e.Type,
+		Length: length,
+	}
+	if item.ITunesExt != nil {
+		enclosure.Duration = parseDuration(item.ITunesExt.Duration)
+	}
+	return enclosure
+}
+
+// parseDuration parses itunes:duration values, which are either plain
+// seconds or [HH:]MM:SS.
+func parseDuration(s string) time.Duration {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+
+	total := 0
+	for _, part := range strings.Split(s, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0
+		}
+		total = total*60 + n
+	}
+	return time.Duration(total) * time.Second
+}
+
+func itemAuthor(item *gofeed.Item) string {
+	if item.Author != nil && item.Author.Name != "" {
+		return item.Author.Name
+	} else if len(item.Authors) > 0 && item.Authors[0].Name != "" {
+		return item.Authors[0].Name
+	} else if item.DublinCoreExt != nil && len(item.DublinCoreExt.Creator) > 0 {
+		return item.DublinCoreExt.Creator[0]
+	}
+	return "Unknown"
+}
+
+// parseCommentExtensions reads the wfw:commentRss and slash:comments
+// extensions that WordPress and many other blogs publish per item.
+func parseCommentExtensions(item *gofeed.Item) (commentsURL string, count int) {
+	if wfw, ok := item.Extensions["wfw"]; ok {
+		if exts := wfw["commentRss"]; len(exts) > 0 {
+			commentsURL = strings.TrimSpace(exts[0].Value)
+		}
+	}
+
+	if slash, ok := item.Extensions["slash"]; ok {
+		if exts := slash["comments"]; len(exts) > 0 {
+			count, _ = strconv.Atoi(strings.TrimSpace(exts[0].Value))
+		}
+	}
+
+	return commentsURL, count
+}
+
+var (
+	blockTagRe = regexp.MustCompile(`(?i)</?(p|br|div|blockquote|li)[^>]*>`)
+	tagRe      = regexp.MustCompile(`<[^>]*>`)
+	blankRe    = regexp.MustCompile(`\n{3,}`)
+)
+
+// stripHTML turns comment HTML into plain text, keeping paragraph breaks.
+func stripHTML(s string) string {
+	s = blockTagRe.ReplaceAllString(s, "\n\n")
+	s = tagRe.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = blankRe.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
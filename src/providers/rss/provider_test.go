@@ -0,0 +1,30 @@
+package rss
+
+import "testing"
+
+func TestMatchURL(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"https://example.com/feed.xml", "https://example.com/feed.xml", true},
+		{"example.com/feed.xml", "https://example.com/feed.xml", true},
+		{"http://example.com/rss", "http://example.com/rss", true},
+		{"https://github.com/golang/go", "https://github.com/golang/go/releases.atom", true},
+		{"github.com/golang/go/issues", "https://github.com/golang/go/releases.atom", true},
+		{"https://github.com/golang/go/commits.atom", "https://github.com/golang/go/commits.atom", true},
+		{"https://github.com/golang", "https://github.com/golang", true},
+		{"ftp://example.com/feed.xml", "", false},
+		{"r/golang", "", false},
+		{"golang", "", false},
+	}
+
+	p := New()
+	for _, tt := range tests {
+		got, ok := p.MatchURL(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("MatchURL(%q) = %q, %v; want %q, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}